@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// alarm is a daily reminder attached to every generated event at a fixed local
+// time of day, whether the event itself is all-day or timed.
+type alarm struct {
+	at        time.Duration
+	action    string
+	attendees []string
+}
+
+// newAlarm builds an alarm from the command line settings. It returns nil if
+// at is empty, which means no reminder should be attached.
+func newAlarm(at, action, emails string) (*alarm, error) {
+	if at == "" {
+		return nil, nil
+	}
+	offset, err := parseClock(at)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid alarm time %q: expected HH:MM", at)
+	}
+	a := &alarm{
+		at:     offset,
+		action: strings.ToLower(action),
+	}
+	switch a.action {
+	case "display":
+	case "email":
+		for _, email := range strings.Split(emails, ",") {
+			if email = strings.TrimSpace(email); email != "" {
+				a.attendees = append(a.attendees, email)
+			}
+		}
+		if len(a.attendees) == 0 {
+			return nil, errors.New("Email alarms need at least one address")
+		}
+	default:
+		return nil, fmt.Errorf("Unknown alarm action %q: expected display or email", action)
+	}
+	return a, nil
+}
+
+// render returns the VALARM component for an event with the given summary and
+// readings, written out as text. start is how long after local midnight the
+// event begins, since triggers are relative to the event start.
+func (a *alarm) render(start time.Duration, summary, readings string) string {
+	trigger := formatDuration(a.at - start)
+	if a.action == "display" {
+		return fmt.Sprintf(displayAlarm, trigger, summary)
+	}
+	var attendees strings.Builder
+	for _, email := range a.attendees {
+		attendees.WriteString("\nATTENDEE:mailto:")
+		attendees.WriteString(email)
+	}
+	return fmt.Sprintf(emailAlarm, trigger, summary, escapeText(readings), attendees.String())
+}
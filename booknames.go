@@ -0,0 +1,389 @@
+package main
+
+// Localized book names, keyed by the English name used in books. Aliases such
+// as "Song of Solomon" are looked up through bookAliases instead.
+
+var spanishBooks = map[string]string{
+	"Genesis":         "Génesis",
+	"Exodus":          "Éxodo",
+	"Leviticus":       "Levítico",
+	"Numbers":         "Números",
+	"Deuteronomy":     "Deuteronomio",
+	"Joshua":          "Josué",
+	"Judges":          "Jueces",
+	"Ruth":            "Rut",
+	"1 Samuel":        "1 Samuel",
+	"2 Samuel":        "2 Samuel",
+	"1 Kings":         "1 Reyes",
+	"2 Kings":         "2 Reyes",
+	"1 Chronicles":    "1 Crónicas",
+	"2 Chronicles":    "2 Crónicas",
+	"Ezra":            "Esdras",
+	"Nehemiah":        "Nehemías",
+	"Tobit":           "Tobías",
+	"Judith":          "Judit",
+	"Esther":          "Ester",
+	"1 Maccabees":     "1 Macabeos",
+	"2 Maccabees":     "2 Macabeos",
+	"Job":             "Job",
+	"Psalms":          "Salmos",
+	"Psalm":           "Salmo",
+	"Proverbs":        "Proverbios",
+	"Ecclesiastes":    "Eclesiastés",
+	"Song of Songs":   "Cantar de los Cantares",
+	"Wisdom":          "Sabiduría",
+	"Sirach":          "Eclesiástico",
+	"Isaiah":          "Isaías",
+	"Jeremiah":        "Jeremías",
+	"Lamentations":    "Lamentaciones",
+	"Baruch":          "Baruc",
+	"Ezekiel":         "Ezequiel",
+	"Daniel":          "Daniel",
+	"Hosea":           "Oseas",
+	"Joel":            "Joel",
+	"Amos":            "Amós",
+	"Obadiah":         "Abdías",
+	"Jonah":           "Jonás",
+	"Micah":           "Miqueas",
+	"Nahum":           "Nahúm",
+	"Habakkuk":        "Habacuc",
+	"Zephaniah":       "Sofonías",
+	"Haggai":          "Ageo",
+	"Zechariah":       "Zacarías",
+	"Malachi":         "Malaquías",
+	"Matthew":         "Mateo",
+	"Mark":            "Marcos",
+	"Luke":            "Lucas",
+	"John":            "Juan",
+	"Acts":            "Hechos",
+	"Romans":          "Romanos",
+	"1 Corinthians":   "1 Corintios",
+	"2 Corinthians":   "2 Corintios",
+	"Galatians":       "Gálatas",
+	"Ephesians":       "Efesios",
+	"Philippians":     "Filipenses",
+	"Colossians":      "Colosenses",
+	"1 Thessalonians": "1 Tesalonicenses",
+	"2 Thessalonians": "2 Tesalonicenses",
+	"1 Timothy":       "1 Timoteo",
+	"2 Timothy":       "2 Timoteo",
+	"Titus":           "Tito",
+	"Philemon":        "Filemón",
+	"Hebrews":         "Hebreos",
+	"James":           "Santiago",
+	"1 Peter":         "1 Pedro",
+	"2 Peter":         "2 Pedro",
+	"1 John":          "1 Juan",
+	"2 John":          "2 Juan",
+	"3 John":          "3 Juan",
+	"Jude":            "Judas",
+	"Revelation":      "Apocalipsis",
+}
+
+var portugueseBooks = map[string]string{
+	"Genesis":         "Gênesis",
+	"Exodus":          "Êxodo",
+	"Leviticus":       "Levítico",
+	"Numbers":         "Números",
+	"Deuteronomy":     "Deuteronômio",
+	"Joshua":          "Josué",
+	"Judges":          "Juízes",
+	"Ruth":            "Rute",
+	"1 Samuel":        "1 Samuel",
+	"2 Samuel":        "2 Samuel",
+	"1 Kings":         "1 Reis",
+	"2 Kings":         "2 Reis",
+	"1 Chronicles":    "1 Crônicas",
+	"2 Chronicles":    "2 Crônicas",
+	"Ezra":            "Esdras",
+	"Nehemiah":        "Neemias",
+	"Tobit":           "Tobias",
+	"Judith":          "Judite",
+	"Esther":          "Ester",
+	"1 Maccabees":     "1 Macabeus",
+	"2 Maccabees":     "2 Macabeus",
+	"Job":             "Jó",
+	"Psalms":          "Salmos",
+	"Psalm":           "Salmo",
+	"Proverbs":        "Provérbios",
+	"Ecclesiastes":    "Eclesiastes",
+	"Song of Songs":   "Cântico dos Cânticos",
+	"Wisdom":          "Sabedoria",
+	"Sirach":          "Eclesiástico",
+	"Isaiah":          "Isaías",
+	"Jeremiah":        "Jeremias",
+	"Lamentations":    "Lamentações",
+	"Baruch":          "Baruc",
+	"Ezekiel":         "Ezequiel",
+	"Daniel":          "Daniel",
+	"Hosea":           "Oseias",
+	"Joel":            "Joel",
+	"Amos":            "Amós",
+	"Obadiah":         "Abdias",
+	"Jonah":           "Jonas",
+	"Micah":           "Miqueias",
+	"Nahum":           "Naum",
+	"Habakkuk":        "Habacuc",
+	"Zephaniah":       "Sofonias",
+	"Haggai":          "Ageu",
+	"Zechariah":       "Zacarias",
+	"Malachi":         "Malaquias",
+	"Matthew":         "Mateus",
+	"Mark":            "Marcos",
+	"Luke":            "Lucas",
+	"John":            "João",
+	"Acts":            "Atos dos Apóstolos",
+	"Romans":          "Romanos",
+	"1 Corinthians":   "1 Coríntios",
+	"2 Corinthians":   "2 Coríntios",
+	"Galatians":       "Gálatas",
+	"Ephesians":       "Efésios",
+	"Philippians":     "Filipenses",
+	"Colossians":      "Colossenses",
+	"1 Thessalonians": "1 Tessalonicenses",
+	"2 Thessalonians": "2 Tessalonicenses",
+	"1 Timothy":       "1 Timóteo",
+	"2 Timothy":       "2 Timóteo",
+	"Titus":           "Tito",
+	"Philemon":        "Filêmon",
+	"Hebrews":         "Hebreus",
+	"James":           "Tiago",
+	"1 Peter":         "1 Pedro",
+	"2 Peter":         "2 Pedro",
+	"1 John":          "1 João",
+	"2 John":          "2 João",
+	"3 John":          "3 João",
+	"Jude":            "Judas",
+	"Revelation":      "Apocalipse",
+}
+
+var polishBooks = map[string]string{
+	"Genesis":         "Rodzaju",
+	"Exodus":          "Wyjścia",
+	"Leviticus":       "Kapłańska",
+	"Numbers":         "Liczb",
+	"Deuteronomy":     "Powtórzonego Prawa",
+	"Joshua":          "Jozuego",
+	"Judges":          "Sędziów",
+	"Ruth":            "Rut",
+	"1 Samuel":        "1 Samuela",
+	"2 Samuel":        "2 Samuela",
+	"1 Kings":         "1 Królewska",
+	"2 Kings":         "2 Królewska",
+	"1 Chronicles":    "1 Kronik",
+	"2 Chronicles":    "2 Kronik",
+	"Ezra":            "Ezdrasza",
+	"Nehemiah":        "Nehemiasza",
+	"Tobit":           "Tobiasza",
+	"Judith":          "Judyty",
+	"Esther":          "Estery",
+	"1 Maccabees":     "1 Machabejska",
+	"2 Maccabees":     "2 Machabejska",
+	"Job":             "Hioba",
+	"Psalms":          "Psalmy",
+	"Psalm":           "Psalm",
+	"Proverbs":        "Przysłów",
+	"Ecclesiastes":    "Koheleta",
+	"Song of Songs":   "Pieśń nad Pieśniami",
+	"Wisdom":          "Mądrości",
+	"Sirach":          "Syracydesa",
+	"Isaiah":          "Izajasza",
+	"Jeremiah":        "Jeremiasza",
+	"Lamentations":    "Lamentacje",
+	"Baruch":          "Barucha",
+	"Ezekiel":         "Ezechiela",
+	"Daniel":          "Daniela",
+	"Hosea":           "Ozeasza",
+	"Joel":            "Joela",
+	"Amos":            "Amosa",
+	"Obadiah":         "Abdiasza",
+	"Jonah":           "Jonasza",
+	"Micah":           "Micheasza",
+	"Nahum":           "Nahuma",
+	"Habakkuk":        "Habakuka",
+	"Zephaniah":       "Sofoniasza",
+	"Haggai":          "Aggeusza",
+	"Zechariah":       "Zachariasza",
+	"Malachi":         "Malachiasza",
+	"Matthew":         "Mateusza",
+	"Mark":            "Marka",
+	"Luke":            "Łukasza",
+	"John":            "Jana",
+	"Acts":            "Dzieje Apostolskie",
+	"Romans":          "Rzymian",
+	"1 Corinthians":   "1 Koryntian",
+	"2 Corinthians":   "2 Koryntian",
+	"Galatians":       "Galacjan",
+	"Ephesians":       "Efezjan",
+	"Philippians":     "Filipian",
+	"Colossians":      "Kolosan",
+	"1 Thessalonians": "1 Tesaloniczan",
+	"2 Thessalonians": "2 Tesaloniczan",
+	"1 Timothy":       "1 Tymoteusza",
+	"2 Timothy":       "2 Tymoteusza",
+	"Titus":           "Tytusa",
+	"Philemon":        "Filemona",
+	"Hebrews":         "Hebrajczyków",
+	"James":           "Jakuba",
+	"1 Peter":         "1 Piotra",
+	"2 Peter":         "2 Piotra",
+	"1 John":          "1 Jana",
+	"2 John":          "2 Jana",
+	"3 John":          "3 Jana",
+	"Jude":            "Judy",
+	"Revelation":      "Apokalipsa",
+}
+
+var frenchBooks = map[string]string{
+	"Genesis":         "Genèse",
+	"Exodus":          "Exode",
+	"Leviticus":       "Lévitique",
+	"Numbers":         "Nombres",
+	"Deuteronomy":     "Deutéronome",
+	"Joshua":          "Josué",
+	"Judges":          "Juges",
+	"Ruth":            "Ruth",
+	"1 Samuel":        "1 Samuel",
+	"2 Samuel":        "2 Samuel",
+	"1 Kings":         "1 Rois",
+	"2 Kings":         "2 Rois",
+	"1 Chronicles":    "1 Chroniques",
+	"2 Chronicles":    "2 Chroniques",
+	"Ezra":            "Esdras",
+	"Nehemiah":        "Néhémie",
+	"Tobit":           "Tobie",
+	"Judith":          "Judith",
+	"Esther":          "Esther",
+	"1 Maccabees":     "1 Maccabées",
+	"2 Maccabees":     "2 Maccabées",
+	"Job":             "Job",
+	"Psalms":          "Psaumes",
+	"Psalm":           "Psaume",
+	"Proverbs":        "Proverbes",
+	"Ecclesiastes":    "Ecclésiaste",
+	"Song of Songs":   "Cantique des Cantiques",
+	"Wisdom":          "Sagesse",
+	"Sirach":          "Siracide",
+	"Isaiah":          "Isaïe",
+	"Jeremiah":        "Jérémie",
+	"Lamentations":    "Lamentations",
+	"Baruch":          "Baruch",
+	"Ezekiel":         "Ézéchiel",
+	"Daniel":          "Daniel",
+	"Hosea":           "Osée",
+	"Joel":            "Joël",
+	"Amos":            "Amos",
+	"Obadiah":         "Abdias",
+	"Jonah":           "Jonas",
+	"Micah":           "Michée",
+	"Nahum":           "Nahum",
+	"Habakkuk":        "Habacuc",
+	"Zephaniah":       "Sophonie",
+	"Haggai":          "Aggée",
+	"Zechariah":       "Zacharie",
+	"Malachi":         "Malachie",
+	"Matthew":         "Matthieu",
+	"Mark":            "Marc",
+	"Luke":            "Luc",
+	"John":            "Jean",
+	"Acts":            "Actes des Apôtres",
+	"Romans":          "Romains",
+	"1 Corinthians":   "1 Corinthiens",
+	"2 Corinthians":   "2 Corinthiens",
+	"Galatians":       "Galates",
+	"Ephesians":       "Éphésiens",
+	"Philippians":     "Philippiens",
+	"Colossians":      "Colossiens",
+	"1 Thessalonians": "1 Thessaloniciens",
+	"2 Thessalonians": "2 Thessaloniciens",
+	"1 Timothy":       "1 Timothée",
+	"2 Timothy":       "2 Timothée",
+	"Titus":           "Tite",
+	"Philemon":        "Philémon",
+	"Hebrews":         "Hébreux",
+	"James":           "Jacques",
+	"1 Peter":         "1 Pierre",
+	"2 Peter":         "2 Pierre",
+	"1 John":          "1 Jean",
+	"2 John":          "2 Jean",
+	"3 John":          "3 Jean",
+	"Jude":            "Jude",
+	"Revelation":      "Apocalypse",
+}
+
+var tagalogBooks = map[string]string{
+	"Genesis":         "Genesis",
+	"Exodus":          "Exodo",
+	"Leviticus":       "Levitico",
+	"Numbers":         "Mga Bilang",
+	"Deuteronomy":     "Deuteronomio",
+	"Joshua":          "Josue",
+	"Judges":          "Mga Hukom",
+	"Ruth":            "Ruth",
+	"1 Samuel":        "1 Samuel",
+	"2 Samuel":        "2 Samuel",
+	"1 Kings":         "1 Mga Hari",
+	"2 Kings":         "2 Mga Hari",
+	"1 Chronicles":    "1 Cronica",
+	"2 Chronicles":    "2 Cronica",
+	"Ezra":            "Ezra",
+	"Nehemiah":        "Nehemias",
+	"Tobit":           "Tobit",
+	"Judith":          "Judit",
+	"Esther":          "Ester",
+	"1 Maccabees":     "1 Macabeo",
+	"2 Maccabees":     "2 Macabeo",
+	"Job":             "Job",
+	"Psalms":          "Mga Awit",
+	"Psalm":           "Awit",
+	"Proverbs":        "Mga Kawikaan",
+	"Ecclesiastes":    "Mangangaral",
+	"Song of Songs":   "Awit ng mga Awit",
+	"Wisdom":          "Karunungan",
+	"Sirach":          "Sirac",
+	"Isaiah":          "Isaias",
+	"Jeremiah":        "Jeremias",
+	"Lamentations":    "Mga Panaghoy",
+	"Baruch":          "Baruc",
+	"Ezekiel":         "Ezekiel",
+	"Daniel":          "Daniel",
+	"Hosea":           "Oseas",
+	"Joel":            "Joel",
+	"Amos":            "Amos",
+	"Obadiah":         "Obadias",
+	"Jonah":           "Jonas",
+	"Micah":           "Mikas",
+	"Nahum":           "Nahum",
+	"Habakkuk":        "Habacuc",
+	"Zephaniah":       "Zefanias",
+	"Haggai":          "Hagai",
+	"Zechariah":       "Zacarias",
+	"Malachi":         "Malakias",
+	"Matthew":         "Mateo",
+	"Mark":            "Marcos",
+	"Luke":            "Lucas",
+	"John":            "Juan",
+	"Acts":            "Mga Gawa",
+	"Romans":          "Roma",
+	"1 Corinthians":   "1 Corinto",
+	"2 Corinthians":   "2 Corinto",
+	"Galatians":       "Galacia",
+	"Ephesians":       "Efeso",
+	"Philippians":     "Filipos",
+	"Colossians":      "Colosas",
+	"1 Thessalonians": "1 Tesalonica",
+	"2 Thessalonians": "2 Tesalonica",
+	"1 Timothy":       "1 Timoteo",
+	"2 Timothy":       "2 Timoteo",
+	"Titus":           "Tito",
+	"Philemon":        "Filemon",
+	"Hebrews":         "Hebreo",
+	"James":           "Santiago",
+	"1 Peter":         "1 Pedro",
+	"2 Peter":         "2 Pedro",
+	"1 John":          "1 Juan",
+	"2 John":          "2 Juan",
+	"3 John":          "3 Juan",
+	"Jude":            "Judas",
+	"Revelation":      "Pahayag",
+}
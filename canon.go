@@ -0,0 +1,281 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// canonBook is a book of the Catholic canon, with the identifiers and
+// abbreviations used to refer to it.
+type canonBook struct {
+	name string
+	// osis is the OSIS book id, e.g. "1Sam"
+	osis string
+	// usfm is the USFM book code, e.g. "1SA"
+	usfm string
+	// abbreviation is the short English name, e.g. "1 Sam"
+	abbreviation string
+	chapters     int
+}
+
+// canon lists the books of the Bible in order. Chapter counts follow the
+// RSVCE.
+var canon = []*canonBook{
+	{name: "Genesis", osis: "Gen", usfm: "GEN", abbreviation: "Gen", chapters: 50},
+	{name: "Exodus", osis: "Exod", usfm: "EXO", abbreviation: "Exod", chapters: 40},
+	{name: "Leviticus", osis: "Lev", usfm: "LEV", abbreviation: "Lev", chapters: 27},
+	{name: "Numbers", osis: "Num", usfm: "NUM", abbreviation: "Num", chapters: 36},
+	{name: "Deuteronomy", osis: "Deut", usfm: "DEU", abbreviation: "Deut", chapters: 34},
+	{name: "Joshua", osis: "Josh", usfm: "JOS", abbreviation: "Josh", chapters: 24},
+	{name: "Judges", osis: "Judg", usfm: "JDG", abbreviation: "Judg", chapters: 21},
+	{name: "Ruth", osis: "Ruth", usfm: "RUT", abbreviation: "Ruth", chapters: 4},
+	{name: "1 Samuel", osis: "1Sam", usfm: "1SA", abbreviation: "1 Sam", chapters: 31},
+	{name: "2 Samuel", osis: "2Sam", usfm: "2SA", abbreviation: "2 Sam", chapters: 24},
+	{name: "1 Kings", osis: "1Kgs", usfm: "1KI", abbreviation: "1 Kgs", chapters: 22},
+	{name: "2 Kings", osis: "2Kgs", usfm: "2KI", abbreviation: "2 Kgs", chapters: 25},
+	{name: "1 Chronicles", osis: "1Chr", usfm: "1CH", abbreviation: "1 Chr", chapters: 29},
+	{name: "2 Chronicles", osis: "2Chr", usfm: "2CH", abbreviation: "2 Chr", chapters: 36},
+	{name: "Ezra", osis: "Ezra", usfm: "EZR", abbreviation: "Ezra", chapters: 10},
+	{name: "Nehemiah", osis: "Neh", usfm: "NEH", abbreviation: "Neh", chapters: 13},
+	{name: "Tobit", osis: "Tob", usfm: "TOB", abbreviation: "Tob", chapters: 14},
+	{name: "Judith", osis: "Jdt", usfm: "JDT", abbreviation: "Jdt", chapters: 16},
+	{name: "Esther", osis: "Esth", usfm: "EST", abbreviation: "Esth", chapters: 10},
+	{name: "1 Maccabees", osis: "1Macc", usfm: "1MA", abbreviation: "1 Macc", chapters: 16},
+	{name: "2 Maccabees", osis: "2Macc", usfm: "2MA", abbreviation: "2 Macc", chapters: 15},
+	{name: "Job", osis: "Job", usfm: "JOB", abbreviation: "Job", chapters: 42},
+	{name: "Psalms", osis: "Ps", usfm: "PSA", abbreviation: "Ps", chapters: 150},
+	{name: "Proverbs", osis: "Prov", usfm: "PRO", abbreviation: "Prov", chapters: 31},
+	{name: "Ecclesiastes", osis: "Eccl", usfm: "ECC", abbreviation: "Eccl", chapters: 12},
+	{name: "Song of Songs", osis: "Song", usfm: "SNG", abbreviation: "Song", chapters: 8},
+	{name: "Wisdom", osis: "Wis", usfm: "WIS", abbreviation: "Wis", chapters: 19},
+	{name: "Sirach", osis: "Sir", usfm: "SIR", abbreviation: "Sir", chapters: 51},
+	{name: "Isaiah", osis: "Isa", usfm: "ISA", abbreviation: "Isa", chapters: 66},
+	{name: "Jeremiah", osis: "Jer", usfm: "JER", abbreviation: "Jer", chapters: 52},
+	{name: "Lamentations", osis: "Lam", usfm: "LAM", abbreviation: "Lam", chapters: 5},
+	{name: "Baruch", osis: "Bar", usfm: "BAR", abbreviation: "Bar", chapters: 6},
+	{name: "Ezekiel", osis: "Ezek", usfm: "EZK", abbreviation: "Ezek", chapters: 48},
+	{name: "Daniel", osis: "Dan", usfm: "DAN", abbreviation: "Dan", chapters: 14},
+	{name: "Hosea", osis: "Hos", usfm: "HOS", abbreviation: "Hos", chapters: 14},
+	{name: "Joel", osis: "Joel", usfm: "JOL", abbreviation: "Joel", chapters: 3},
+	{name: "Amos", osis: "Amos", usfm: "AMO", abbreviation: "Amos", chapters: 9},
+	{name: "Obadiah", osis: "Obad", usfm: "OBA", abbreviation: "Obad", chapters: 1},
+	{name: "Jonah", osis: "Jonah", usfm: "JON", abbreviation: "Jonah", chapters: 4},
+	{name: "Micah", osis: "Mic", usfm: "MIC", abbreviation: "Mic", chapters: 7},
+	{name: "Nahum", osis: "Nah", usfm: "NAM", abbreviation: "Nah", chapters: 3},
+	{name: "Habakkuk", osis: "Hab", usfm: "HAB", abbreviation: "Hab", chapters: 3},
+	{name: "Zephaniah", osis: "Zeph", usfm: "ZEP", abbreviation: "Zeph", chapters: 3},
+	{name: "Haggai", osis: "Hag", usfm: "HAG", abbreviation: "Hag", chapters: 2},
+	{name: "Zechariah", osis: "Zech", usfm: "ZEC", abbreviation: "Zech", chapters: 14},
+	{name: "Malachi", osis: "Mal", usfm: "MAL", abbreviation: "Mal", chapters: 4},
+	{name: "Matthew", osis: "Matt", usfm: "MAT", abbreviation: "Matt", chapters: 28},
+	{name: "Mark", osis: "Mark", usfm: "MRK", abbreviation: "Mark", chapters: 16},
+	{name: "Luke", osis: "Luke", usfm: "LUK", abbreviation: "Luke", chapters: 24},
+	{name: "John", osis: "John", usfm: "JHN", abbreviation: "John", chapters: 21},
+	{name: "Acts", osis: "Acts", usfm: "ACT", abbreviation: "Acts", chapters: 28},
+	{name: "Romans", osis: "Rom", usfm: "ROM", abbreviation: "Rom", chapters: 16},
+	{name: "1 Corinthians", osis: "1Cor", usfm: "1CO", abbreviation: "1 Cor", chapters: 16},
+	{name: "2 Corinthians", osis: "2Cor", usfm: "2CO", abbreviation: "2 Cor", chapters: 13},
+	{name: "Galatians", osis: "Gal", usfm: "GAL", abbreviation: "Gal", chapters: 6},
+	{name: "Ephesians", osis: "Eph", usfm: "EPH", abbreviation: "Eph", chapters: 6},
+	{name: "Philippians", osis: "Phil", usfm: "PHP", abbreviation: "Phil", chapters: 4},
+	{name: "Colossians", osis: "Col", usfm: "COL", abbreviation: "Col", chapters: 4},
+	{name: "1 Thessalonians", osis: "1Thess", usfm: "1TH", abbreviation: "1 Thess", chapters: 5},
+	{name: "2 Thessalonians", osis: "2Thess", usfm: "2TH", abbreviation: "2 Thess", chapters: 3},
+	{name: "1 Timothy", osis: "1Tim", usfm: "1TI", abbreviation: "1 Tim", chapters: 6},
+	{name: "2 Timothy", osis: "2Tim", usfm: "2TI", abbreviation: "2 Tim", chapters: 4},
+	{name: "Titus", osis: "Titus", usfm: "TIT", abbreviation: "Titus", chapters: 3},
+	{name: "Philemon", osis: "Phlm", usfm: "PHM", abbreviation: "Phlm", chapters: 1},
+	{name: "Hebrews", osis: "Heb", usfm: "HEB", abbreviation: "Heb", chapters: 13},
+	{name: "James", osis: "Jas", usfm: "JAS", abbreviation: "Jas", chapters: 5},
+	{name: "1 Peter", osis: "1Pet", usfm: "1PE", abbreviation: "1 Pet", chapters: 5},
+	{name: "2 Peter", osis: "2Pet", usfm: "2PE", abbreviation: "2 Pet", chapters: 3},
+	{name: "1 John", osis: "1John", usfm: "1JN", abbreviation: "1 John", chapters: 5},
+	{name: "2 John", osis: "2John", usfm: "2JN", abbreviation: "2 John", chapters: 1},
+	{name: "3 John", osis: "3John", usfm: "3JN", abbreviation: "3 John", chapters: 1},
+	{name: "Jude", osis: "Jude", usfm: "JUD", abbreviation: "Jude", chapters: 1},
+	{name: "Revelation", osis: "Rev", usfm: "REV", abbreviation: "Rev", chapters: 22},
+}
+
+// canonAliases are names of books that aren't their canon name.
+var canonAliases = map[string]string{
+	"Psalm":                "Psalms",
+	"Song of Solomon":      "Song of Songs",
+	"Acts of the Apostles": "Acts",
+}
+
+var canonBooks = func() map[string]*canonBook {
+	m := make(map[string]*canonBook, len(canon)+len(canonAliases))
+	for _, b := range canon {
+		m[b.name] = b
+	}
+	for alias, name := range canonAliases {
+		m[alias] = m[name]
+	}
+	return m
+}()
+
+// books are the English book names plans may use: the name of each book in
+// the canon and its aliases.
+var books = func() map[string]struct{} {
+	m := make(map[string]struct{}, len(canonBooks))
+	for name := range canonBooks {
+		m[name] = struct{}{}
+	}
+	return m
+}()
+
+// lookupCanon returns the canon entry for an English book name, or nil.
+func lookupCanon(name string) *canonBook {
+	return canonBooks[name]
+}
+
+// canonAbbreviations maps each book's English abbreviation to its name.
+var canonAbbreviations = func() map[string]string {
+	m := make(map[string]string, len(canon))
+	for _, b := range canon {
+		m[b.abbreviation] = b.name
+	}
+	return m
+}()
+
+var canonOSIS = func() map[string]*canonBook {
+	m := make(map[string]*canonBook, len(canon))
+	for _, b := range canon {
+		m[b.osis] = b
+	}
+	return m
+}()
+
+// osisReadings reads OSIS references, such as those osisIDs returns, back
+// into readings, one for each run of references to the same book. It
+// returns nil if any of them isn't a reference to a book of the canon.
+func osisReadings(ids []string) []*reading {
+	var readings []*reading
+	for _, id := range ids {
+		b, passage, ok := parseOSIS(id)
+		if !ok {
+			return nil
+		}
+		book := b.name
+		if b.name == "Psalms" && passage != "" {
+			// Plans read the psalms one at a time.
+			book = "Psalm"
+		}
+		if n := len(readings); n > 0 && readings[n-1].book == book && passage != "" && len(readings[n-1].passages) > 0 {
+			readings[n-1].passages = append(readings[n-1].passages, passage)
+			continue
+		}
+		r := &reading{book: book}
+		if passage != "" {
+			r.passages = []string{passage}
+		}
+		readings = append(readings, r)
+	}
+	return readings
+}
+
+// parseOSIS reads an OSIS reference such as "Gen", "Ps.119.1-Ps.119.16" or
+// "Gen.1.26-Gen.2.3", returning its book and the passage as a plan writes
+// it, such as "119:1-16", or "" for the whole book.
+func parseOSIS(id string) (b *canonBook, passage string, ok bool) {
+	parts := strings.SplitN(id, "-", 2)
+	b, start, ok := parseOSISVerse(parts[0])
+	if !ok {
+		return nil, "", false
+	}
+	if start.chapter == 0 {
+		return b, "", len(parts) == 1
+	}
+	passage = b.passageVerse(start, 0)
+	if len(parts) == 1 {
+		return b, passage, true
+	}
+	endBook, end, ok := parseOSISVerse(parts[1])
+	if !ok || endBook != b || end.chapter == 0 {
+		return nil, "", false
+	}
+	chapter := 0
+	if start.verse != 0 {
+		chapter = start.chapter
+	}
+	return b, passage + "-" + b.passageVerse(end, chapter), true
+}
+
+// parseOSISVerse reads "Gen", "Gen.1" or "Gen.1.26".
+func parseOSISVerse(s string) (*canonBook, verseRef, bool) {
+	fields := strings.Split(s, ".")
+	b := canonOSIS[fields[0]]
+	if b == nil || len(fields) > 3 {
+		return nil, verseRef{}, false
+	}
+	var n [2]int
+	for i, f := range fields[1:] {
+		var err error
+		if n[i], err = strconv.Atoi(f); err != nil || n[i] < 1 {
+			return nil, verseRef{}, false
+		}
+	}
+	return b, verseRef{n[0], n[1]}, true
+}
+
+// passageVerse writes v as parsePassage reads it after a passage in the
+// given chapter: just the verse within that chapter or a book with one
+// chapter, otherwise "3" or "3:16".
+func (b *canonBook) passageVerse(v verseRef, chapter int) string {
+	switch {
+	case v.verse == 0:
+		return strconv.Itoa(v.chapter)
+	case v.chapter == chapter || b.chapters == 1:
+		return strconv.Itoa(v.verse)
+	default:
+		return strconv.Itoa(v.chapter) + ":" + strconv.Itoa(v.verse)
+	}
+}
+
+// osisIDs returns the OSIS references of r, one for each passage, such as
+// "1Sam.3.1-1Sam.3.10" and "1Sam.15". A reading without passages is the
+// whole book. It returns nil for books not in the canon.
+func osisIDs(r *reading) []string {
+	b := lookupCanon(r.book)
+	if b == nil {
+		return nil
+	}
+	if len(r.passages) == 0 {
+		return []string{b.osis}
+	}
+	ids := make([]string, 0, len(r.passages))
+	var chapter int
+	for _, p := range r.passages {
+		var id string
+		id, chapter = b.osisRef(p, chapter)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// usfmCode returns the USFM book code of r, or "" for books not in the
+// canon.
+func usfmCode(r *reading) string {
+	if b := lookupCanon(r.book); b != nil {
+		return b.usfm
+	}
+	return ""
+}
+
+// readingIDs returns X-BIBLE-OSIS and X-BIBLE-USFM properties listing the
+// OSIS references and USFM book codes of readings, separated by spaces, each
+// starting with a newline.
+func readingIDs(readings []*reading) string {
+	var osis, usfm []string
+	for _, r := range readings {
+		osis = append(osis, osisIDs(r)...)
+		if code := usfmCode(r); code != "" {
+			usfm = append(usfm, code)
+		}
+	}
+	if len(osis) == 0 {
+		return ""
+	}
+	return "\nX-BIBLE-OSIS:" + strings.Join(osis, " ") +
+		"\nX-BIBLE-USFM:" + strings.Join(usfm, " ")
+}
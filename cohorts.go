@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// cohort is a group reading the plan together from its own start date.
+type cohort struct {
+	id           string
+	name         string
+	start        time.Time
+	translations []string
+	calendarName string
+	output       string
+	// color is a CSS color name used for the cohort's events in a merged
+	// calendar.
+	color string
+}
+
+// loadCohorts reads a cohort file such as:
+//
+//	# name | start date | translations | calendar name | output file | color
+//	St. Mary's Tuesday group | 2021-01-01 | RSVCE,ESV | St. Mary's Bible in a Year
+//	Young adults | 2021-09-01 | | | young-adults.ics | teal
+//
+// Only the name and start date are required. The name also identifies the
+// cohort's UIDs, so renaming a cohort gives it new events. Output files are
+// names in the output directory, one for each cohort.
+func loadCohorts(path string) ([]*cohort, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cohorts []*cohort
+	seen := make(map[string]bool)
+	outputs := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "|")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if len(fields) < 2 || fields[0] == "" {
+			return nil, fmt.Errorf("%s:%d: expected \"name | start date | translations | calendar name | output file | color\"", path, n)
+		}
+		c := &cohort{name: fields[0], id: slugify(fields[0])}
+		if c.id == "" {
+			return nil, fmt.Errorf("%s:%d: invalid cohort name %q", path, n, c.name)
+		}
+		if c.start, err = time.Parse("2006-01-02", fields[1]); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid start date %q", path, n, fields[1])
+		}
+		if len(fields) > 2 {
+			c.translations = splitList(fields[2])
+		}
+		if len(fields) > 3 {
+			c.calendarName = fields[3]
+		}
+		c.output = c.id + ".ics"
+		if len(fields) > 4 && fields[4] != "" {
+			c.output = fields[4]
+		}
+		if c.output != filepath.Base(c.output) || c.output == "." || c.output == ".." {
+			return nil, fmt.Errorf("%s:%d: invalid output file %q: expected a file name such as young-adults.ics", path, n, c.output)
+		}
+		if len(fields) > 5 && fields[5] != "" {
+			c.color = strings.ToLower(fields[5])
+			if !cssColors[c.color] {
+				return nil, fmt.Errorf("%s:%d: unknown color %q: expected a CSS color name such as teal", path, n, fields[5])
+			}
+		}
+		if seen[c.id] {
+			return nil, fmt.Errorf("%s:%d: duplicate cohort %q", path, n, c.name)
+		}
+		seen[c.id] = true
+		if other, ok := outputs[c.output]; ok {
+			return nil, fmt.Errorf("%s:%d: cohorts %q and %q both write %s", path, n, other, c.name, c.output)
+		}
+		outputs[c.output] = c.name
+		cohorts = append(cohorts, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(cohorts) == 0 {
+		return nil, errors.New("No cohorts in " + path)
+	}
+	return cohorts, nil
+}
+
+// cssColors are the CSS3 color names, the values RFC 7986 allows for COLOR.
+var cssColors = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, name := range []string{
+		"aliceblue", "antiquewhite", "aqua", "aquamarine", "azure", "beige",
+		"bisque", "black", "blanchedalmond", "blue", "blueviolet", "brown",
+		"burlywood", "cadetblue", "chartreuse", "chocolate", "coral",
+		"cornflowerblue", "cornsilk", "crimson", "cyan", "darkblue",
+		"darkcyan", "darkgoldenrod", "darkgray", "darkgreen", "darkgrey",
+		"darkkhaki", "darkmagenta", "darkolivegreen", "darkorange",
+		"darkorchid", "darkred", "darksalmon", "darkseagreen", "darkslateblue",
+		"darkslategray", "darkslategrey", "darkturquoise", "darkviolet",
+		"deeppink", "deepskyblue", "dimgray", "dimgrey", "dodgerblue",
+		"firebrick", "floralwhite", "forestgreen", "fuchsia", "gainsboro",
+		"ghostwhite", "gold", "goldenrod", "gray", "green", "greenyellow",
+		"grey", "honeydew", "hotpink", "indianred", "indigo", "ivory", "khaki",
+		"lavender", "lavenderblush", "lawngreen", "lemonchiffon", "lightblue",
+		"lightcoral", "lightcyan", "lightgoldenrodyellow", "lightgray",
+		"lightgreen", "lightgrey", "lightpink", "lightsalmon", "lightseagreen",
+		"lightskyblue", "lightslategray", "lightslategrey", "lightsteelblue",
+		"lightyellow", "lime", "limegreen", "linen", "magenta", "maroon",
+		"mediumaquamarine", "mediumblue", "mediumorchid", "mediumpurple",
+		"mediumseagreen", "mediumslateblue", "mediumspringgreen",
+		"mediumturquoise", "mediumvioletred", "midnightblue", "mintcream",
+		"mistyrose", "moccasin", "navajowhite", "navy", "oldlace", "olive",
+		"olivedrab", "orange", "orangered", "orchid", "palegoldenrod",
+		"palegreen", "paleturquoise", "palevioletred", "papayawhip",
+		"peachpuff", "peru", "pink", "plum", "powderblue", "purple", "red",
+		"rosybrown", "royalblue", "saddlebrown", "salmon", "sandybrown",
+		"seagreen", "seashell", "sienna", "silver", "skyblue", "slateblue",
+		"slategray", "slategrey", "snow", "springgreen", "steelblue", "tan",
+		"teal", "thistle", "tomato", "turquoise", "violet", "wheat", "white",
+		"whitesmoke", "yellow", "yellowgreen",
+	} {
+		m[name] = true
+	}
+	return m
+}()
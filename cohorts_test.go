@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// writeTemp writes content to a temporary file removed after the test,
+// returning its path.
+func writeTemp(t *testing.T, pattern, content string) string {
+	t.Helper()
+	f, err := ioutil.TempFile("", pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestLoadCohorts(t *testing.T) {
+	cohorts, err := loadCohorts(writeTemp(t, "cohorts", "# name | start\n"+
+		"St. Mary's Tuesday group | 2021-01-01 | RSVCE,ESV | St. Mary's Bible in a Year\n"+
+		"Young adults | 2021-09-01 | | | young-adults.ics | Teal\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cohorts) != 2 {
+		t.Fatalf("got %d cohorts, want 2", len(cohorts))
+	}
+	if c := cohorts[0]; c.id != "st-marys-tuesday-group" || c.output != c.id+".ics" || c.color != "" {
+		t.Errorf("got id %q, output %q, color %q", c.id, c.output, c.color)
+	}
+	if c := cohorts[1]; c.color != "teal" {
+		t.Errorf("got color %q, want teal", c.color)
+	}
+}
+
+func TestLoadCohortsErrors(t *testing.T) {
+	for _, content := range []string{
+		"Young adults | 2021-09-01 | | | | #008080\n",
+		"Young adults | 2021-09-01 | | | | blurple\n",
+		"Young adults | 2021-31-09\n",
+		"!!! | 2021-09-01\n",
+		"Young adults | 2021-09-01\nYoung Adults | 2021-10-01\n",
+		"Young adults | 2021-09-01 | | | group.ics\nSeniors | 2021-10-01 | | | group.ics\n",
+		"Young adults | 2021-09-01 | | | seniors.ics\nSeniors | 2021-10-01\n",
+		"Young adults | 2021-09-01 | | | ../young-adults.ics\n",
+		"Young adults | 2021-09-01 | | | /tmp/young-adults.ics\n",
+		"Young adults | 2021-09-01 | | | groups/young-adults.ics\n",
+		"Young adults | 2021-09-01 | | | ..\n",
+	} {
+		if _, err := loadCohorts(writeTemp(t, "cohorts", content)); err == nil {
+			t.Errorf("loaded %q", content)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// config is a TOML file holding the settings for a calendar, so a repeatable
+// job doesn't need a long command line. For example:
+//
+//	plan = "plan.txt"
+//	output = "bibleinayear.ics"
+//	start = "2021-09-01"
+//	lang = "en"
+//	translations = ["RSVCE", "ESV"]
+//	links = "biblegateway"
+//	description = "plain"
+//	recurrence = "yearly"
+//
+//	[event]
+//	at = "06:30"
+//	duration = "30m"
+//	tz = "America/Chicago"
+//
+//	[alarm]
+//	at = "06:00"
+//	action = "email"
+//	email = ["reader@example.com"]
+//
+// Settings are named after their flags; in the event table they are -at,
+// -duration and -tz, in the alarm table -alarm, -alarm-action and
+// -alarm-email, and in the templates table -summary-template and so on.
+// Flags given on the command line win over the file.
+type config struct {
+	Plan         string
+	Output       string
+	Previous     string
+	Start        string
+	Lang         string
+	Name         string
+	Translations []string
+	Links        string
+	Description  string
+	References   string
+	EnDash       *bool `toml:"en-dash"`
+	Recurrence   string
+	Media        string
+	Cohorts      string
+	Merge        *bool
+	Timestamp    string
+	Progress     int
+	Tracks       []string
+	TrackLabels  *bool `toml:"track-labels"`
+	Books        []string
+	Testaments   []string
+	Periods      []string
+	Compact      *bool
+	Templates    struct {
+		Summary     string
+		Description string
+		Location    string
+	}
+	Event struct {
+		At       string
+		Duration string
+		TZ       string
+	}
+	Alarm struct {
+		At     string
+		Action string
+		Email  []string
+	}
+}
+
+func loadConfig(path string) (*config, error) {
+	c := &config{}
+	md, err := toml.DecodeFile(path, c)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("%s: unknown settings %s", path, strings.Join(keys, ", "))
+	}
+	return c, nil
+}
+
+// applyConfigFile applies the config file at path, if not empty, to the
+// flags of a subcommand, returning the plan it names.
+func applyConfigFile(fs *flag.FlagSet, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	c, err := loadConfig(path)
+	if err != nil {
+		return "", err
+	}
+	if err := c.apply(fs); err != nil {
+		return "", err
+	}
+	return c.Plan, nil
+}
+
+// flags returns the settings in c by flag name, leaving out those the file
+// doesn't set.
+func (c *config) flags() map[string]string {
+	values := map[string]string{
+		"previous":     c.Previous,
+		"start":        c.Start,
+		"lang":         c.Lang,
+		"name":         c.Name,
+		"translations": strings.Join(c.Translations, ","),
+		"links":        c.Links,
+		"description":  c.Description,
+		"references":   c.References,
+		"recurrence":   c.Recurrence,
+		"media":        c.Media,
+		"cohorts":      c.Cohorts,
+		"timestamp":    c.Timestamp,
+		"tracks":       strings.Join(c.Tracks, ","),
+		"books":        strings.Join(c.Books, ","),
+		"testaments":   strings.Join(c.Testaments, ","),
+		"periods":      strings.Join(c.Periods, ","),
+
+		"summary-template":     c.Templates.Summary,
+		"description-template": c.Templates.Description,
+		"location-template":    c.Templates.Location,
+
+		"at":           c.Event.At,
+		"duration":     c.Event.Duration,
+		"tz":           c.Event.TZ,
+		"alarm":        c.Alarm.At,
+		"alarm-action": c.Alarm.Action,
+		"alarm-email":  strings.Join(c.Alarm.Email, ","),
+	}
+	if c.Progress != 0 {
+		values["progress"] = strconv.Itoa(c.Progress)
+	}
+	if c.EnDash != nil {
+		values["en-dash"] = strconv.FormatBool(*c.EnDash)
+	}
+	if c.TrackLabels != nil {
+		values["track-labels"] = strconv.FormatBool(*c.TrackLabels)
+	}
+	if c.Compact != nil {
+		values["compact"] = strconv.FormatBool(*c.Compact)
+	}
+	if c.Merge != nil {
+		values["merge"] = strconv.FormatBool(*c.Merge)
+	}
+	for name, value := range values {
+		if value == "" {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// apply sets each flag in fs from c unless it was given on the command line.
+// Settings for flags fs doesn't have, such as -alarm for the digest command,
+// are left out.
+func (c *config) apply(fs *flag.FlagSet) error {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	values := c.flags()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if given[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, values[name]); err != nil {
+			return fmt.Errorf("Invalid %s %q in config: %v", name, values[name], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+// testFlags returns some of the flags the subcommands define, leaving out
+// -alarm and the other alarm flags as digest does.
+func testFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.String("start", "", "")
+	fs.String("translations", "", "")
+	fs.String("at", "", "")
+	fs.Duration("duration", 0, "")
+	fs.Int("progress", 0, "")
+	fs.Bool("en-dash", false, "")
+	fs.Bool("track-labels", true, "")
+	fs.Bool("compact", false, "")
+	fs.Bool("merge", true, "")
+	return fs
+}
+
+func TestApplyConfig(t *testing.T) {
+	tests := []struct {
+		name, config string
+		args         []string
+		want         map[string]string
+		plan         string
+		wantErr      bool
+	}{
+		{
+			name:   "file",
+			config: "plan = \"plan.txt\"\nstart = \"2021-09-01\"\nprogress = 30\n[event]\nat = \"06:30\"\nduration = \"30m\"\n",
+			want:   map[string]string{"start": "2021-09-01", "progress": "30", "at": "06:30", "duration": "30m0s"},
+			plan:   "plan.txt",
+		},
+		{
+			name:   "command line wins",
+			config: "start = \"2021-09-01\"\nen-dash = true\n",
+			args:   []string{"-start", "2022-01-01", "-en-dash=false"},
+			want:   map[string]string{"start": "2022-01-01", "en-dash": "false"},
+		},
+		{
+			name:   "bools",
+			config: "en-dash = true\ntrack-labels = false\ncompact = true\nmerge = false\n",
+			want:   map[string]string{"en-dash": "true", "track-labels": "false", "compact": "true", "merge": "false"},
+		},
+		{
+			name:   "unset bools",
+			config: "start = \"2021-09-01\"\n",
+			want:   map[string]string{"en-dash": "false", "track-labels": "true", "compact": "false", "merge": "true"},
+		},
+		{
+			name:   "lists",
+			config: "translations = [\"RSVCE\", \"ESV\"]\n",
+			want:   map[string]string{"translations": "RSVCE,ESV"},
+		},
+		{
+			name:   "flags the subcommand lacks",
+			config: "cohorts = \"cohorts.txt\"\n[alarm]\nat = \"06:00\"\nemail = [\"reader@example.com\"]\n",
+			want:   map[string]string{"at": ""},
+		},
+		{name: "unknown key", config: "colour = \"teal\"\n", wantErr: true},
+		{name: "unknown key in table", config: "[event]\nwhen = \"06:30\"\n", wantErr: true},
+		{name: "invalid value", config: "[event]\nduration = \"soon\"\n", wantErr: true},
+		{name: "invalid TOML", config: "start = \n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := testFlags()
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			plan, err := applyConfigFile(fs, writeTemp(t, "config", tt.config))
+			if tt.wantErr {
+				if err == nil {
+					t.Error("no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plan != tt.plan {
+				t.Errorf("plan %q, want %q", plan, tt.plan)
+			}
+			for name, want := range tt.want {
+				if got := fs.Lookup(name).Value.String(); got != want {
+					t.Errorf("-%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyNoConfig(t *testing.T) {
+	if plan, err := applyConfigFile(testFlags(), ""); plan != "" || err != nil {
+		t.Errorf("applyConfigFile without a file = %q, %v", plan, err)
+	}
+}
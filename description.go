@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// descriptionParts is everything shown in an event's description, before it
+// is rendered in one of the descriptionStyles.
+type descriptionParts struct {
+	intro    string
+	readings []*reading
+	// references are the readings as shown, in the calendar's language
+	references []string
+	notes      []string
+	links      []link
+}
+
+// newDescriptionParts collects the description of d. startsPeriod is whether
+// d is the first day of its period, which shows the period's description if
+// the plan doesn't give an introduction. ep may be nil.
+func newDescriptionParts(l *locale, refs referenceFormat, links linkProvider, translations []string, d *day, startsPeriod bool, ep *episode) *descriptionParts {
+	p := &descriptionParts{intro: d.intro, readings: d.readings, references: refs.format(l, d.readings), notes: d.notes}
+	if p.intro == "" && startsPeriod {
+		p.intro = d.period.description(l)
+	}
+	for _, translation := range translations {
+		p.links = append(p.links, link{text: translation, url: links(d.readings, translation)})
+	}
+	if ep != nil {
+		p.links = append(p.links, link{text: ep.link(l), url: ep.url})
+	}
+	return p
+}
+
+// labelTracks puts each reading's track before its reference, as in
+// "Psalm: Psalm 19".
+func (p *descriptionParts) labelTracks(l *locale) {
+	for i, r := range p.readings {
+		if r.track != "" {
+			p.references[i] = trackName(l, r.track) + ": " + p.references[i]
+		}
+	}
+}
+
+// link is a titled URL in a description.
+type link struct {
+	text, url string
+}
+
+// descriptionStyle renders a description, returning the DESCRIPTION value and
+// any further properties, each starting with a newline.
+type descriptionStyle func(l *locale, p *descriptionParts) (description, properties string)
+
+// descriptionStyles are the ways clients want descriptions formatted. Google
+// Calendar renders HTML in DESCRIPTION; Apple Calendar and Outlook show it as
+// markup, so they need plain text, optionally with an HTML alternative.
+var descriptionStyles = map[string]descriptionStyle{
+	"google": googleDescription,
+	"plain":  plainDescription,
+	"html":   htmlDescription,
+}
+
+func lookupDescriptionStyle(name string) (descriptionStyle, error) {
+	if style, ok := descriptionStyles[name]; ok {
+		return style, nil
+	}
+	names := make([]string, 0, len(descriptionStyles))
+	for n := range descriptionStyles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("Unknown description style %q: expected one of %s", name, strings.Join(names, ", "))
+}
+
+// googleDescription puts HTML directly in DESCRIPTION.
+func googleDescription(l *locale, p *descriptionParts) (string, string) {
+	var s strings.Builder
+	if p.intro != "" {
+		s.WriteString(escapeText(html.EscapeString(p.intro)))
+		s.WriteString("<br><br>")
+	}
+	for i, ref := range p.references {
+		if i > 0 {
+			s.WriteString("<br><br>")
+		}
+		s.WriteString(escapeText(html.EscapeString(ref)))
+	}
+	for _, note := range p.notes {
+		s.WriteString("<br><br>")
+		s.WriteString(escapeText(html.EscapeString(note)))
+	}
+	for _, link := range p.links {
+		s.WriteString("<br><br>")
+		s.WriteString(escapeText(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(link.url), html.EscapeString(link.text))))
+	}
+	return s.String(), ""
+}
+
+// plainDescription writes text with bare URLs, which every client shows
+// correctly.
+func plainDescription(l *locale, p *descriptionParts) (string, string) {
+	return escapeText(plainText(l, p)), ""
+}
+
+// htmlDescription writes plain text in DESCRIPTION and an HTML version in
+// X-ALT-DESC for clients that support it.
+func htmlDescription(l *locale, p *descriptionParts) (string, string) {
+	description, _ := plainDescription(l, p)
+	body := "<html><body>" + htmlText(l, p) + "</body></html>"
+	return description, "\nX-ALT-DESC;FMTTYPE=text/html:" + escapeText(body)
+}
+
+// htmlText lays the description out as HTML paragraphs.
+func htmlText(l *locale, p *descriptionParts) string {
+	var s strings.Builder
+	if p.intro != "" {
+		s.WriteString("<p>" + html.EscapeString(p.intro) + "</p>")
+	}
+	s.WriteString("<p>")
+	for i, ref := range p.references {
+		if i > 0 {
+			s.WriteString("<br>")
+		}
+		s.WriteString(html.EscapeString(ref))
+	}
+	s.WriteString("</p>")
+	for _, note := range p.notes {
+		s.WriteString("<p>" + html.EscapeString(note) + "</p>")
+	}
+	if len(p.links) > 0 {
+		s.WriteString("<p>")
+		for i, link := range p.links {
+			if i > 0 {
+				s.WriteString("<br>")
+			}
+			s.WriteString(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(link.url), html.EscapeString(link.text)))
+		}
+		s.WriteString("</p>")
+	}
+	return s.String()
+}
+
+// plainText lays the description out as paragraphs of unescaped text.
+func plainText(l *locale, p *descriptionParts) string {
+	var paragraphs []string
+	if p.intro != "" {
+		paragraphs = append(paragraphs, p.intro)
+	}
+	paragraphs = append(paragraphs, strings.Join(p.references, "\n"))
+	paragraphs = append(paragraphs, p.notes...)
+	if len(p.links) > 0 {
+		var lines []string
+		for _, link := range p.links {
+			lines = append(lines, link.text+": "+link.url)
+		}
+		paragraphs = append(paragraphs, strings.Join(lines, "\n"))
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// change is a difference between two versions of the same plan day. A nil
+// before or after means the day was added or removed.
+type change struct {
+	number        int
+	before, after *day
+}
+
+func (c change) kind() string {
+	switch {
+	case c.before == nil:
+		return "added"
+	case c.after == nil:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bibleinayear diff OLD NEW")
+		fmt.Fprintln(fs.Output(), "OLD and NEW are plan files or calendars generated by this tool.")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("Please provide two plans or calendars to compare")
+	}
+
+	before, err := loadDays(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := loadDays(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	return writeChanges(os.Stdout, diffDays(before, after))
+}
+
+// diffDays compares two versions of a plan by day number.
+func diffDays(before, after []*day) []change {
+	changes := make(map[int]*change)
+	get := func(n int) *change {
+		c, ok := changes[n]
+		if !ok {
+			c = &change{number: n}
+			changes[n] = c
+		}
+		return c
+	}
+	for _, d := range before {
+		get(d.number).before = d
+	}
+	for _, d := range after {
+		get(d.number).after = d
+	}
+
+	var result []change
+	for _, c := range changes {
+		if c.before != nil && c.after != nil &&
+			samePeriod(c.before.period, c.after.period) &&
+			sameReadings(c.before.readings, c.after.readings) {
+			continue
+		}
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].number < result[j].number })
+	return result
+}
+
+// sameReadings compares readings by the passages they cover, treating
+// alternative book names such as "Song of Solomon" and "Song of Songs", and
+// ways of writing a passage such as "119:20" and "20" after "119:1-16", as
+// equal.
+func sameReadings(a, b []*reading) bool {
+	ka, kb := readingKeys(a), readingKeys(b)
+	if len(ka) != len(kb) {
+		return false
+	}
+	for i := range ka {
+		if ka[i] != kb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// readingKeys returns the OSIS references of readings, or for books not in
+// the canon their name and passages.
+func readingKeys(readings []*reading) []string {
+	var keys []string
+	for _, r := range readings {
+		if ids := osisIDs(r); ids != nil {
+			keys = append(keys, ids...)
+			continue
+		}
+		keys = append(keys, primaryBook(r.book)+" "+strings.Join(r.passages, ", "))
+	}
+	return keys
+}
+
+func writeChanges(w io.Writer, changes []change) error {
+	en := locales["en"]
+	bw := bufio.NewWriter(w)
+	counts := make(map[string]int)
+	for _, c := range changes {
+		counts[c.kind()]++
+		fmt.Fprintf(bw, "Day %d %s\n", c.number, c.kind())
+		if c.before != nil {
+			fmt.Fprintf(bw, "  - %s: %s\n", c.before.period.name(en), readingsText(c.before.readings))
+		}
+		if c.after != nil {
+			fmt.Fprintf(bw, "  + %s: %s\n", c.after.period.name(en), readingsText(c.after.readings))
+		}
+	}
+	fmt.Fprintf(bw, "%d added, %d removed, %d modified\n", counts["added"], counts["removed"], counts["modified"])
+	return bw.Flush()
+}
+
+// loadDays reads a plan from path, which may be either a plan file or a
+// calendar generated by this tool.
+func loadDays(path string) ([]*day, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if isCalendar(r) {
+		calendars, err := parseICS(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		days, skipped := calendarDays(calendars)
+		for _, summary := range skipped {
+			log.Printf("%s: skipping event %q: not a plan day", path, summary)
+		}
+		return days, nil
+	}
+	return parsePlan(r)
+}
+
+// isCalendar reports whether r starts with BEGIN:VCALENDAR, without
+// consuming any input.
+func isCalendar(r *bufio.Reader) bool {
+	start, _ := r.Peek(len("BEGIN:VCALENDAR") + 3)
+	return strings.HasPrefix(strings.TrimLeft(string(start), "\ufeff \r\n"), "BEGIN:VCALENDAR")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func parsePlanString(t *testing.T, s string) []*day {
+	t.Helper()
+	days, err := parsePlan(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return days
+}
+
+func TestDiffDays(t *testing.T) {
+	before := parsePlanString(t, "Early World\n"+
+		"Day 1 Genesis 1-2 Psalm 19\n"+
+		"Day 2 Song of Solomon 1\n"+
+		"Day 3 Genesis 5\n"+
+		"Patriarchs\n"+
+		"Day 4 Genesis 12\n"+
+		"Day 6 Genesis 14\n")
+	after := parsePlanString(t, "Early World\n"+
+		"Day 1 Genesis 1-2 Psalm 19\n"+
+		"Day 2 Song of Songs 1\n"+
+		"Day 3 Genesis 5-6\n"+
+		"Day 4 Genesis 12\n"+
+		"Day 5 Genesis 13\n")
+	changes := diffDays(before, after)
+	var got []string
+	for _, c := range changes {
+		got = append(got, fmt.Sprintf("%d %s", c.number, c.kind()))
+	}
+	if want := "3 modified, 4 modified, 5 added, 6 removed"; strings.Join(got, ", ") != want {
+		t.Errorf("changes %s, want %s", strings.Join(got, ", "), want)
+	}
+
+	var buf bytes.Buffer
+	if err := writeChanges(&buf, changes); err != nil {
+		t.Fatal(err)
+	}
+	const want = "Day 3 modified\n" +
+		"  - Early World: Genesis 5\n" +
+		"  + Early World: Genesis 5-6\n" +
+		"Day 4 modified\n" +
+		"  - Patriarchs: Genesis 12\n" +
+		"  + Early World: Genesis 12\n" +
+		"Day 5 added\n" +
+		"  + Early World: Genesis 13\n" +
+		"Day 6 removed\n" +
+		"  - Patriarchs: Genesis 14\n" +
+		"1 added, 1 removed, 2 modified\n"
+	if buf.String() != want {
+		t.Errorf("writeChanges wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	days := parsePlanString(t, "Early World\nDay 1 Psalm 119:1-16, 20 Song of Songs 2\n")
+	same := parsePlanString(t, "Early World\nDay 1 Psalms 119:1-16, 119:20 Song of Solomon 2\n")
+	changes := diffDays(days, same)
+	if len(changes) > 0 {
+		t.Errorf("%d changes", len(changes))
+	}
+	var buf bytes.Buffer
+	if err := writeChanges(&buf, changes); err != nil {
+		t.Fatal(err)
+	}
+	if want := "0 added, 0 removed, 0 modified\n"; buf.String() != want {
+		t.Errorf("writeChanges wrote %q, want %q", buf.String(), want)
+	}
+}
+
+// TestDiffReferenceStyles checks that a calendar in every reference and
+// description style reads back with no changes from its plan.
+func TestDiffReferenceStyles(t *testing.T) {
+	plans := []string{"testdata/notes.txt", "plan.txt"}
+	for _, plan := range plans {
+		if _, err := os.Stat(plan); err != nil {
+			t.Skip(err)
+		}
+		want := readPlanSource(t, planFile(plan))
+		for _, style := range referenceStyles {
+			for name, describe := range descriptionStyles {
+				t.Run(plan+"/"+style+"/"+name, func(t *testing.T) {
+					g := newTestGenerator(t)
+					g.refs = referenceFormat{style: style}
+					g.describe = describe
+					changes := diffDays(want, importCalendar(t, g, planFile(plan)))
+					if len(changes) > 0 {
+						t.Errorf("%d changes, first day %d %s", len(changes), changes[0].number, changes[0].kind())
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	htmlpkg "html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// digest is one email covering one or more consecutive days of the plan.
+type digest struct {
+	subject string
+	days    []*day
+	dates   []time.Time
+	// starts is whether each day is the first of its period
+	starts []bool
+}
+
+func runDigest(args []string) error {
+	fs := flag.NewFlagSet("digest", flag.ContinueOnError)
+	var (
+		start  = fs.String("start", defaultStartDate.Format("2006-01-02"), "date of day 1 of the plan")
+		from   = fs.String("from", time.Now().Format("2006-01-02"), "first date to send readings for")
+		to     = fs.String("to", "", "last date to send readings for (default same as -from)")
+		weekly = fs.Bool("weekly", false, "send one digest per week instead of one per day")
+		recur  = fs.String("recurrence", "yearly", "how the calendar's events repeat: yearly or none")
+		lang   = fs.String("lang", "en", "language of the digest")
+		media  = fs.String("media", "", "file mapping days to companion podcast episodes")
+		sender = fs.String("sender", "", "From address")
+		rcpt   = fs.String("rcpt", "", "comma-separated recipient addresses")
+		server = fs.String("smtp", "", "SMTP server host:port to deliver through (print messages if empty)")
+		user   = fs.String("smtp-user", "", "SMTP username; the password is read from SMTP_PASSWORD")
+		outDir = fs.String("out", "", "directory to write .eml files to instead of printing or sending")
+		conf   = fs.String("config", "", "TOML file of settings, as for generating calendars; flags given on the command line override it")
+	)
+	reading := addReadingFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bibleinayear digest [flags] plan.txt")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	planpath, err := applyConfigFile(fs, *conf)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 1 {
+		planpath = fs.Arg(0)
+	}
+	if fs.NArg() > 1 || planpath == "" {
+		fs.Usage()
+		return errors.New("Please provide path to plan file")
+	}
+
+	l, err := lookupLocale(*lang)
+	if err != nil {
+		return err
+	}
+	opts, err := reading.options(l)
+	if err != nil {
+		return err
+	}
+	episodes, err := loadMedia(*media)
+	if err != nil {
+		return err
+	}
+	startDate, err := time.Parse("2006-01-02", *start)
+	if err != nil {
+		return fmt.Errorf("Invalid start date %q: %v", *start, err)
+	}
+	sched, err := readingSchedule(startDate, *recur)
+	if err != nil {
+		return err
+	}
+	first, err := time.Parse("2006-01-02", *from)
+	if err != nil {
+		return fmt.Errorf("Invalid date %q: %v", *from, err)
+	}
+	last := first
+	if *to != "" {
+		if last, err = time.Parse("2006-01-02", *to); err != nil {
+			return fmt.Errorf("Invalid date %q: %v", *to, err)
+		}
+	}
+	if last.Before(first) {
+		return errors.New("The -to date is before the -from date")
+	}
+	recipients := splitList(*rcpt)
+	if *server != "" && (*sender == "" || len(recipients) == 0) {
+		return errors.New("Sending by SMTP needs -sender and -rcpt")
+	}
+
+	planfile, err := os.Open(planpath)
+	if err != nil {
+		return err
+	}
+	days, err := parsePlan(planfile)
+	planfile.Close()
+	if err != nil {
+		return err
+	}
+
+	length := 1
+	if *weekly {
+		length = 7
+	}
+	digests := buildDigests(l, opts.name, sched, days, first, last, length)
+	if len(digests) == 0 {
+		return errors.New("No readings between those dates")
+	}
+
+	var auth smtp.Auth
+	if *user != "" {
+		auth = smtp.PlainAuth("", *user, os.Getenv("SMTP_PASSWORD"), strings.Split(*server, ":")[0])
+	}
+	for i, dg := range digests {
+		var msg bytes.Buffer
+		if err := writeDigest(&msg, l, opts, episodes, dg, *sender, recipients, time.Now()); err != nil {
+			return err
+		}
+		switch {
+		case *outDir != "":
+			name := filepath.Join(*outDir, fmt.Sprintf("digest-%s.eml", dg.dates[0].Format("2006-01-02")))
+			if err := ioutil.WriteFile(name, msg.Bytes(), 0644); err != nil {
+				return err
+			}
+		case *server != "":
+			if err := smtp.SendMail(*server, auth, *sender, recipients, msg.Bytes()); err != nil {
+				return fmt.Errorf("Sending digest for %s: %v", dg.dates[0].Format("2006-01-02"), err)
+			}
+		default:
+			if i > 0 {
+				fmt.Println()
+			}
+			if _, err := os.Stdout.Write(msg.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// buildDigests groups the plan days falling between first and last, inclusive,
+// into digests of length dates each, with subjects starting with the
+// calendar's name.
+func buildDigests(l *locale, name string, sched *schedule, days []*day, first, last time.Time, length int) []*digest {
+	byNumber := make(map[int]*day, len(days))
+	starts := make(map[int]bool, len(days))
+	for i, d := range days {
+		byNumber[d.number] = d
+		starts[d.number] = i == 0 || !samePeriod(d.period, days[i-1].period)
+	}
+
+	var digests []*digest
+	for date := first; !date.After(last); date = date.AddDate(0, 0, length) {
+		dg := &digest{}
+		for i := 0; i < length && !date.AddDate(0, 0, i).After(last); i++ {
+			n, ok := sched.dayOn(date.AddDate(0, 0, i))
+			if d, found := byNumber[n]; ok && found {
+				dg.days = append(dg.days, d)
+				dg.dates = append(dg.dates, date.AddDate(0, 0, i))
+				dg.starts = append(dg.starts, starts[n])
+			}
+		}
+		if len(dg.days) == 0 {
+			continue
+		}
+		dg.subject = name + " – " + dayTitle(l, dg.days[0])
+		if len(dg.days) > 1 {
+			dg.subject = fmt.Sprintf("%s – %s – %s", name,
+				dg.dates[0].Format("2006-01-02"), dg.dates[len(dg.dates)-1].Format("2006-01-02"))
+		}
+		digests = append(digests, dg)
+	}
+	return digests
+}
+
+func dayTitle(l *locale, d *day) string {
+	return fmt.Sprintf(l.message("summary"), d.number, d.period.name(l))
+}
+
+// writeDigest writes dg as a multipart/alternative email with text and HTML
+// versions.
+func writeDigest(w io.Writer, l *locale, opts *readingOptions, episodes *media, dg *digest, sender string, recipients []string, now time.Time) error {
+	var text, html strings.Builder
+	for i, d := range dg.days {
+		parts := newDescriptionParts(l, opts.refs, opts.links, opts.translations, d, dg.starts[i], episodes.episode(d.number))
+		title := dg.dates[i].Format("2006-01-02") + " · " + dayTitle(l, d)
+		if i > 0 {
+			text.WriteString("\n\n")
+		}
+		text.WriteString(title + "\n\n" + plainText(l, parts) + "\n")
+		html.WriteString("<h2>" + htmlpkg.EscapeString(title) + "</h2>" + htmlText(l, parts))
+	}
+
+	mw := multipart.NewWriter(w)
+	header := []string{
+		"From: " + sender,
+		"To: " + strings.Join(recipients, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", dg.subject),
+		"Date: " + now.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	if _, err := io.WriteString(w, strings.Join(header, "\r\n")+"\r\n\r\n"); err != nil {
+		return err
+	}
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text.String()},
+		{"text/html; charset=utf-8", "<html><body>" + html.String() + "</body></html>"},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := io.WriteString(qp, part.body); err != nil {
+			return err
+		}
+		if err := qp.Close(); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpMessage is what a client sent to fakeSMTP in one session.
+type smtpMessage struct {
+	from string
+	rcpt []string
+	data []byte
+}
+
+// fakeSMTP accepts SMTP sessions on a local port, sending each message it
+// receives on the returned channel, until the listener is closed.
+func fakeSMTP(t *testing.T) (net.Listener, <-chan *smtpMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := make(chan *smtpMessage, 10)
+	go func() {
+		defer close(messages)
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if msg := serveSMTP(textproto.NewConn(conn)); msg != nil {
+				messages <- msg
+			}
+		}
+	}()
+	return ln, messages
+}
+
+// serveSMTP answers one session with the few commands smtp.SendMail sends
+// to a server without extensions.
+func serveSMTP(c *textproto.Conn) *smtpMessage {
+	defer c.Close()
+	msg := &smtpMessage{}
+	c.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return nil
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case verb == "EHLO" || verb == "HELO":
+			c.PrintfLine("250 localhost")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			msg.from = line[len("MAIL FROM:"):]
+			c.PrintfLine("250 OK")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			msg.rcpt = append(msg.rcpt, line[len("RCPT TO:"):])
+			c.PrintfLine("250 OK")
+		case verb == "DATA":
+			c.PrintfLine("354 Go ahead")
+			if msg.data, err = c.ReadDotBytes(); err != nil {
+				return nil
+			}
+			c.PrintfLine("250 OK")
+		case verb == "QUIT":
+			c.PrintfLine("221 Bye")
+			return msg
+		default:
+			c.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+// TestDigestSMTP sends a digest through a fake SMTP server, checking the
+// envelope and both versions of the message.
+func TestDigestSMTP(t *testing.T) {
+	ln, messages := fakeSMTP(t)
+	err := runDigest([]string{
+		"-smtp", ln.Addr().String(),
+		"-sender", "leader@example.com",
+		"-rcpt", "a@example.com, b@example.com",
+		"-start", "2021-01-01", "-from", "2021-01-05",
+		"-name", "Group Reading",
+		"plan.txt",
+	})
+	ln.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []*smtpMessage
+	for msg := range messages {
+		got = append(got, msg)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d messages, want 1", len(got))
+	}
+	msg := got[0]
+	if msg.from != "<leader@example.com>" {
+		t.Errorf("MAIL FROM %s", msg.from)
+	}
+	if rcpt := strings.Join(msg.rcpt, " "); rcpt != "<a@example.com> <b@example.com>" {
+		t.Errorf("RCPT TO %s", rcpt)
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(string(msg.data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Group Reading – Day 5: Early World"; subject != want {
+		t.Errorf("Subject %q, want %q", subject, want)
+	}
+	if to := m.Header.Get("To"); to != "a@example.com, b@example.com" {
+		t.Errorf("To %q", to)
+	}
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type %q: %v", m.Header.Get("Content-Type"), err)
+	}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for _, want := range []struct{ contentType, text string }{
+		{"text/plain; charset=utf-8", "Genesis 10-11"},
+		{"text/html; charset=utf-8", "<h2>2021-01-05 · Day 5: Early World</h2>"},
+	} {
+		part, err := mr.NextRawPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := part.Header.Get("Content-Type"); ct != want.contentType {
+			t.Errorf("part Content-Type %q, want %q", ct, want.contentType)
+		}
+		body, err := ioutil.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(body), want.text) {
+			t.Errorf("%s part %q doesn't contain %q", want.contentType, body, want.text)
+		}
+	}
+	if _, err := mr.NextRawPart(); err == nil {
+		t.Error("more than two parts")
+	}
+}
+
+// TestDigestRecurrence checks which days of a 400-day plan digests cover
+// after its first year, for calendars that recur yearly and ones that don't.
+func TestDigestRecurrence(t *testing.T) {
+	days := readPlanSource(t, syntheticPlan(400))
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		recurrence string
+		date       time.Time
+		want       int
+	}{
+		{"yearly", start, 1},
+		{"yearly", start.AddDate(0, 0, 364), 365},
+		{"yearly", start.AddDate(1, 0, 0), 1},
+		{"yearly", start.AddDate(1, 1, 4), 36},
+		{"none", start, 1},
+		{"none", start.AddDate(0, 0, 364), 365},
+		{"none", start.AddDate(1, 0, 0), 366},
+		{"none", start.AddDate(0, 0, 399), 400},
+		{"none", start.AddDate(0, 0, 400), 0},
+		{"none", start.AddDate(0, 0, -1), 0},
+	}
+	for _, tt := range tests {
+		sched, err := readingSchedule(start, tt.recurrence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digests := buildDigests(locales["en"], "Group Reading", sched, days, tt.date, tt.date, 1)
+		var got int
+		if len(digests) > 0 {
+			got = digests[0].days[0].number
+		}
+		if got != tt.want {
+			t.Errorf("%s on %s: day %d, want %d", tt.recurrence, tt.date.Format("2006-01-02"), got, tt.want)
+		}
+	}
+	if _, err := readingSchedule(start, "monthly"); err == nil {
+		t.Error("readingSchedule accepted monthly")
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// readingFilter keeps the parts of a plan some readers want, such as only
+// the New Testament or only the Psalms. Days left without readings get no
+// event.
+type readingFilter struct {
+	// books, testaments and tracks, when not empty, each list what a
+	// reading must be in to be kept.
+	books      map[*canonBook]bool
+	testaments map[string]bool
+	tracks     []string
+	// periods, when not empty, keeps only the days in them.
+	periods []*period
+	// compact schedules the days kept one after another, instead of on
+	// their dates in the whole plan.
+	compact bool
+}
+
+// testaments are the testaments of the books of the canon, "old" or "new".
+var testaments = func() map[*canonBook]string {
+	m := make(map[*canonBook]string, len(canon))
+	testament := "old"
+	for _, b := range canon {
+		if b.name == "Matthew" {
+			testament = "new"
+		}
+		m[b] = testament
+	}
+	return m
+}()
+
+// newReadingFilter returns a filter keeping readings of the given books or
+// ranges of books, such as "Matthew-John", in the given testaments and
+// tracks, on days in the given periods. It returns nil if there is nothing
+// to filter.
+func newReadingFilter(books, testamentNames, tracks, periods []string, compact bool) (*readingFilter, error) {
+	if len(books) == 0 && len(testamentNames) == 0 && len(tracks) == 0 && len(periods) == 0 {
+		return nil, nil
+	}
+	f := &readingFilter{tracks: tracks, compact: compact}
+	for _, item := range books {
+		bs, err := bookRange(item)
+		if err != nil {
+			return nil, err
+		}
+		if f.books == nil {
+			f.books = make(map[*canonBook]bool)
+		}
+		for _, b := range bs {
+			f.books[b] = true
+		}
+	}
+	for _, t := range testamentNames {
+		t = strings.ToLower(t)
+		if t != "old" && t != "new" {
+			return nil, fmt.Errorf("Unknown testament %q: expected old or new", t)
+		}
+		if f.testaments == nil {
+			f.testaments = make(map[string]bool)
+		}
+		f.testaments[t] = true
+	}
+	for _, name := range periods {
+		f.periods = append(f.periods, lookupPeriod(name))
+	}
+	return f, nil
+}
+
+// keep drops the readings of d the filter doesn't want, returning whether
+// d should still have an event. A nil filter keeps everything.
+func (f *readingFilter) keep(d *day) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.periods) > 0 && !f.inPeriods(d) {
+		return false
+	}
+	kept := d.readings[:0]
+	for _, r := range d.readings {
+		if f.keepReading(r) {
+			kept = append(kept, r)
+		}
+	}
+	d.readings = kept
+	return len(d.readings) > 0
+}
+
+func (f *readingFilter) inPeriods(d *day) bool {
+	for _, p := range f.periods {
+		if samePeriod(p, d.period) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepReading returns whether r is in the filter's books, testaments and
+// tracks. Books outside the canon are only kept if neither books nor
+// testaments are given.
+func (f *readingFilter) keepReading(r *reading) bool {
+	b := lookupCanon(r.book)
+	if len(f.books) > 0 && !f.books[b] {
+		return false
+	}
+	if len(f.testaments) > 0 && (b == nil || !f.testaments[testaments[b]]) {
+		return false
+	}
+	return len(filterTracks([]*reading{r}, f.tracks)) > 0
+}
+
+// planContents records the periods and tracks a plan uses, to check the
+// names given to a filter against.
+type planContents struct {
+	periods map[string]bool
+	tracks  map[string]bool
+}
+
+func newPlanContents() *planContents {
+	return &planContents{periods: make(map[string]bool), tracks: make(map[string]bool)}
+}
+
+// add records d's period and tracks. It must be called before the filter
+// drops any of d's readings.
+func (c *planContents) add(d *day) {
+	if d.period != nil {
+		c.periods[d.period.id] = true
+	}
+	for _, r := range d.readings {
+		c.tracks[strings.ToLower(r.track)] = true
+	}
+}
+
+// check returns an error for periods that are neither known nor headers of
+// the plan, for tracks no reading of the plan is in, and when the filter
+// kept none of the plan's days.
+func (f *readingFilter) check(c *planContents, kept int) error {
+	if f == nil {
+		return nil
+	}
+	for _, p := range f.periods {
+		if !c.periods[p.id] && periodsByName[p.id] == nil {
+			return fmt.Errorf("Unknown period %q: not a known period or a period of the plan", p.name(locales["en"]))
+		}
+	}
+	for _, t := range f.tracks {
+		if !c.tracks[strings.ToLower(t)] {
+			return fmt.Errorf("Unknown track %q: no reading of the plan is in it", t)
+		}
+	}
+	if kept == 0 {
+		return errors.New("The filter leaves no readings")
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestReadingFilter generates testdata/notes.txt with filters, checking
+// which days get events, on which dates, and that they keep their UIDs.
+func TestReadingFilter(t *testing.T) {
+	tests := []struct {
+		name                               string
+		books, testaments, tracks, periods []string
+		compact                            bool
+		// days are the plan days kept, and dates the days of the
+		// schedule they are on.
+		days, dates []int
+		readings    string
+	}{
+		{
+			name:     "book",
+			books:    []string{"Genesis"},
+			days:     []int{1, 2, 3, 4},
+			dates:    []int{1, 2, 3, 4},
+			readings: "Genesis Genesis Genesis Genesis",
+		},
+		{
+			name:       "new testament compact",
+			testaments: []string{"new"},
+			compact:    true,
+			days:       []int{5},
+			dates:      []int{1},
+			readings:   "Acts",
+		},
+		{
+			name:     "book range and track",
+			books:    []string{"1 Samuel-Psalms", "Acts"},
+			tracks:   []string{"narrative", "supporting"},
+			days:     []int{5},
+			dates:    []int{5},
+			readings: "1 Samuel Acts",
+		},
+		{
+			name:     "period compact",
+			periods:  []string{"Patriarchs"},
+			compact:  true,
+			days:     []int{3, 4},
+			dates:    []int{1, 2},
+			readings: "Genesis Psalm Genesis Psalm",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGenerator(t)
+			var err error
+			if g.filter, err = newReadingFilter(tt.books, tt.testaments, tt.tracks, tt.periods, tt.compact); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := g.writeCalendar(&buf, planFile("testdata/notes.txt"), nil); err != nil {
+				t.Fatal(err)
+			}
+			cals, err := parseICS(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			events := cals[0].children("VEVENT")
+			if len(events) != len(tt.days) {
+				t.Fatalf("got %d events, want %d", len(events), len(tt.days))
+			}
+			for i, ev := range events {
+				if uid, _ := g.uid(tt.days[i]); ev.value("UID") != uid {
+					t.Errorf("event %d: UID %s, want day %d's", i, ev.value("UID"), tt.days[i])
+				}
+				if want := formatDate(g.sched.date(tt.dates[i])); ev.value("DTSTART") != want {
+					t.Errorf("event %d: DTSTART %s, want %s", i, ev.value("DTSTART"), want)
+				}
+			}
+			var books []string
+			days, _ := calendarDays(cals)
+			for _, d := range days {
+				for _, r := range d.readings {
+					books = append(books, r.book)
+				}
+			}
+			if got := strings.Join(books, " "); got != tt.readings {
+				t.Errorf("readings %q, want %q", got, tt.readings)
+			}
+		})
+	}
+}
+
+func TestNewReadingFilter(t *testing.T) {
+	if f, err := newReadingFilter(nil, nil, nil, nil, true); f != nil || err != nil {
+		t.Errorf("got %v, %v for no filter", f, err)
+	}
+	if _, err := newReadingFilter(nil, []string{"middle"}, nil, nil, false); err == nil {
+		t.Error("accepted an unknown testament")
+	}
+	if _, err := newReadingFilter([]string{"Revelation-Genesis"}, nil, nil, nil, false); err == nil {
+		t.Error("accepted books out of order")
+	}
+}
+
+// TestReadingFilterErrors checks that names the plan doesn't use, and
+// filters leaving nothing, are reported instead of writing an empty calendar.
+func TestReadingFilterErrors(t *testing.T) {
+	tests := []struct {
+		name                   string
+		books, tracks, periods []string
+	}{
+		{name: "unknown period", periods: []string{"Narnia"}},
+		{name: "unused track", tracks: []string{"Gospels"}},
+		{name: "nothing kept", books: []string{"Revelation"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGenerator(t)
+			var err error
+			if g.filter, err = newReadingFilter(tt.books, nil, tt.tracks, tt.periods, false); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := g.writeCalendar(&buf, planFile("testdata/notes.txt"), nil); err == nil {
+				t.Error("wrote a calendar")
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// generator writes a plan as an iCalendar file.
+type generator struct {
+	locale       *locale
+	name         string
+	translations []string
+	refs         referenceFormat
+	links        linkProvider
+	sched        *schedule
+	alarm        *alarm
+	describe     descriptionStyle
+	templates    *eventTemplates
+	episodes     *media
+	revs         *revisions
+	// rrule is the events' RRULE property, starting with a newline, or
+	// empty if they don't repeat.
+	rrule string
+	// cohort distinguishes the UIDs of calendars for different groups. It
+	// is empty for the default UIDs in uids.go.
+	cohort string
+	// prefix, category and color mark a cohort's events when several
+	// cohorts share one calendar.
+	prefix   string
+	category string
+	color    string
+	// filter, if not nil, keeps only some of the readings, and the days
+	// that have any.
+	filter *readingFilter
+	// trackLabels shows each reading's track before it, as in "Psalm:
+	// Psalm 19".
+	trackLabels bool
+}
+
+// recurrences are the RRULE properties events can have. By default each
+// reading repeats on the same date every year.
+var recurrences = map[string]string{
+	"yearly": "\nRRULE:FREQ=YEARLY",
+	"none":   "",
+}
+
+// uid returns the stable UID of a day's event. Days beyond the first year
+// have UIDs derived from day 1's UID and the day number. Cohort UIDs are
+// derived from the default UID and the cohort id, so they never change
+// between runs.
+func (g *generator) uid(day int) (string, error) {
+	if day < 1 {
+		return "", fmt.Errorf("UID not found for %d", day)
+	}
+	base, ok := uids[day]
+	if !ok {
+		base = uuid.NewSHA1(uuid.MustParse(uids[1]), []byte(strconv.Itoa(day))).String()
+	}
+	if g.cohort == "" {
+		return base, nil
+	}
+	return uuid.NewSHA1(uuid.MustParse(base), []byte(g.cohort)).String(), nil
+}
+
+// planSource opens the plan to generate from. It is read once for each
+// calendar or cohort, rather than held in memory.
+type planSource func() (io.ReadCloser, error)
+
+func planFile(path string) planSource {
+	return func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}
+}
+
+// progressFunc is told how many events have been written so far.
+type progressFunc func(events int)
+
+// writeFile writes the calendar for the plan to path.
+func (g *generator) writeFile(path string, plan planSource, progress progressFunc) error {
+	return writeFile(path, func(w io.Writer) error {
+		return g.writeCalendar(w, plan, progress)
+	})
+}
+
+// writeFile fills path using write. It writes to a temporary file in the
+// same directory, which only replaces path if write succeeds, so an error
+// partway through never leaves path truncated. This matters as the plan is
+// read while writing, and path may be the -previous calendar.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		return fail(err)
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := f.Chmod(mode); err != nil {
+		return fail(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+func (g *generator) writeCalendar(w io.Writer, plan planSource, progress progressFunc) error {
+	return writeMerged(w, g.name, []*generator{g}, plan, progress)
+}
+
+// writeMerged writes one calendar called name holding the events of every
+// generator. The generators share a time zone. Only one day of the plan is
+// held in memory at a time, so plans may be any length, though the previous
+// calendar in each generator's revisions is held whole. progress may be nil.
+func writeMerged(w io.Writer, name string, gens []*generator, plan planSource, progress progressFunc) error {
+	err := writeContentLines(w, fmt.Sprintf(header, name))
+	if err != nil {
+		return err
+	}
+	if tz := gens[0].sched.timezone(); tz != "" {
+		err = writeContentLines(w, tz)
+		if err != nil {
+			return err
+		}
+	}
+	var events int
+	for _, g := range gens {
+		r, err := plan()
+		if err != nil {
+			return err
+		}
+		p := newPlanReader(r)
+		// prev is the last day written, and held the last day skipped
+		// with an introduction, for the next day of its period. kept
+		// counts the days written, which are scheduled one after another
+		// in a compact calendar.
+		var prev, held *day
+		var kept int
+		contents := newPlanContents()
+		for {
+			d, err := p.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				r.Close()
+				return err
+			}
+			contents.add(d)
+			if !g.filter.keep(d) {
+				if d.intro != "" {
+					held = d
+				}
+				continue
+			}
+			if held != nil && d.intro == "" && samePeriod(held.period, d.period) {
+				d.intro = held.intro
+			}
+			held = nil
+			kept++
+			slot := d.number
+			if g.filter != nil && g.filter.compact {
+				slot = kept
+			}
+			if err := g.writeEvent(w, d, slot, prev == nil || !samePeriod(d.period, prev.period)); err != nil {
+				r.Close()
+				return err
+			}
+			prev = d
+			if events++; progress != nil {
+				progress(events)
+			}
+		}
+		if err := r.Close(); err != nil {
+			return err
+		}
+		if err := g.filter.check(contents, kept); err != nil {
+			return err
+		}
+	}
+	return writeContentLines(w, footer)
+}
+
+// writeEvent writes the VEVENT for d on the slot'th day of the schedule,
+// which is d's own day unless a filter compacts the calendar. The UID is
+// always d's. startsPeriod is whether d is the first day of its period.
+func (g *generator) writeEvent(w io.Writer, d *day, slot int, startsPeriod bool) error {
+	l := g.locale
+	dtstart, dtend := g.sched.bounds(slot)
+	uid, err := g.uid(d.number)
+	if err != nil {
+		return err
+	}
+	ep := g.episodes.episode(d.number)
+	parts := newDescriptionParts(l, g.refs, g.links, g.translations, d, startsPeriod, ep)
+	if g.trackLabels {
+		parts.labelTracks(l)
+	}
+	summary := escapeText(g.prefix + fmt.Sprintf(l.message("summary"), d.number, d.period.name(l)))
+	// extra holds optional properties followed by optional components
+	description, extra := g.describe(l, parts)
+	if g.templates != nil {
+		data := newEventData(l, g.sched.date(slot), d, parts)
+		if summary, description, extra, err = g.applyTemplates(data, summary, description, extra); err != nil {
+			return fmt.Errorf("Day %d: %v", d.number, err)
+		}
+	}
+	extra += readingIDs(d.readings)
+	if ep != nil {
+		extra += g.episodes.properties(ep)
+	}
+	if g.category != "" {
+		extra += "\nCATEGORIES:" + escapeText(g.category)
+	}
+	if g.color != "" {
+		extra += "\nCOLOR:" + g.color
+	}
+	if g.alarm != nil {
+		extra += "\n" + g.alarm.render(g.sched.startOffset(), summary, strings.Join(parts.references, "; "))
+	}
+	ev, err := g.revs.revise(uid, func(sequence int, modified string) string {
+		return fmt.Sprintf(event, dtstart, dtend, g.rrule, g.revs.stamp, uid, sequence, modified, description, summary, extra)
+	})
+	if err != nil {
+		return err
+	}
+	return writeContentLines(w, ev)
+}
+
+// applyTemplates replaces the summary and description with those from the
+// user's templates, if given, and adds a LOCATION property to extra.
+func (g *generator) applyTemplates(data *eventData, summary, description, extra string) (string, string, string, error) {
+	text, err := execute(g.templates.summary, data)
+	if err != nil {
+		return "", "", "", err
+	}
+	if text != "" {
+		summary = escapeText(g.prefix + text)
+	}
+	if text, err = execute(g.templates.description, data); err != nil {
+		return "", "", "", err
+	}
+	if text != "" {
+		// The style's extra properties, like X-ALT-DESC, would no longer
+		// match the description.
+		description, extra = escapeText(text), ""
+	}
+	if text, err = execute(g.templates.location, data); err != nil {
+		return "", "", "", err
+	}
+	if text != "" {
+		extra += "\nLOCATION:" + escapeText(text)
+	}
+	return summary, description, extra, nil
+}
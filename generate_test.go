@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// syntheticPlan is a plan of n days with a new period every 30 days. It is
+// produced as it is read, so the input takes no memory either.
+func syntheticPlan(n int) planSource {
+	return func() (io.ReadCloser, error) {
+		r, w := io.Pipe()
+		go func() {
+			bw := bufio.NewWriter(w)
+			for i := 1; i <= n; i++ {
+				if i%30 == 1 {
+					fmt.Fprintf(bw, "Period %d\n> Introduction to period %d\n", i/30+1, i/30+1)
+				}
+				fmt.Fprintf(bw, "Day %d Genesis %d-%d Psalm %d\n", i, i%50+1, i%50+2, i%150+1)
+				fmt.Fprintf(bw, "> Note for day %d\n", i)
+			}
+			w.CloseWithError(bw.Flush())
+		}()
+		return r, nil
+	}
+}
+
+// newTestGenerator returns a generator with the default settings and a fixed
+// DTSTAMP.
+func newTestGenerator(tb testing.TB) *generator {
+	revs, err := loadRevisions("", time.Date(2021, 1, 12, 15, 14, 54, 0, time.UTC))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	sched, err := newSchedule(defaultStartDate, "", 0, "")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	l := locales["en"]
+	return &generator{
+		locale:       l,
+		name:         l.message("calendar"),
+		translations: l.translations,
+		refs:         defaultReferences,
+		links:        bibleGatewayLinks,
+		sched:        sched,
+		describe:     googleDescription,
+		revs:         revs,
+		rrule:        recurrences["yearly"],
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestGolden compares generated calendars with the golden files in
+// testdata. After an intended change to the output, run
+//
+//	go test -run TestGolden -update
+//
+// and review the changes to the golden files.
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		plan  string
+		setup func(t *testing.T, g *generator)
+	}{
+		{
+			name: "default",
+			plan: "plan.txt",
+		},
+		{
+			name: "timed-es-plain",
+			plan: "testdata/notes.txt",
+			setup: func(t *testing.T, g *generator) {
+				var err error
+				g.locale = locales["es"]
+				g.name = g.locale.message("calendar")
+				g.translations = g.locale.translations
+				g.describe = plainDescription
+				if g.sched, err = newSchedule(defaultStartDate, "06:30", 20*time.Minute, "America/Chicago"); err != nil {
+					t.Fatal(err)
+				}
+				if g.alarm, err = newAlarm("06:00", "display", ""); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "cohort-html",
+			plan: "testdata/notes.txt",
+			setup: func(t *testing.T, g *generator) {
+				var err error
+				g.cohort = "young-adults"
+				g.name = "Bible in a Year (Young adults)"
+				g.describe = htmlDescription
+				if g.sched, err = newSchedule(time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC), "", 0, ""); err != nil {
+					t.Fatal(err)
+				}
+				if g.alarm, err = newAlarm("07:00", "email", "reader@example.com"); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGenerator(t)
+			if tt.setup != nil {
+				tt.setup(t, g)
+			}
+			var got bytes.Buffer
+			if err := g.writeCalendar(&got, planFile(tt.plan), nil); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", tt.name+".ics")
+			if *update {
+				if err := ioutil.WriteFile(golden, got.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareLines(t, got.String(), string(want))
+		})
+	}
+}
+
+// TestWriteFileKeepsOutputOnError checks that a plan error partway through
+// leaves the previous calendar in place.
+func TestWriteFileKeepsOutputOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bibleinayear")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bibleinayear.ics")
+	if err := ioutil.WriteFile(path, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	plan := func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("Early World\nDay 1 Genesis 1-2\nDay\n")), nil
+	}
+	if err := newTestGenerator(t).writeFile(path, plan, nil); err == nil {
+		t.Fatal("writeFile succeeded with an invalid plan")
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("got %d files, want only the calendar", len(files))
+	}
+	if data, err := ioutil.ReadFile(path); err != nil || string(data) != "previous" {
+		t.Errorf("calendar is %q, %v; want it unchanged", data, err)
+	}
+
+	if err := newTestGenerator(t).writeFile(path, planFile("testdata/notes.txt"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadFile(path); err != nil || !strings.HasPrefix(string(data), "BEGIN:VCALENDAR") {
+		t.Errorf("calendar is %q, %v; want it replaced", data, err)
+	}
+}
+
+// compareLines reports the first line where got differs from want.
+func compareLines(t *testing.T, got, want string) {
+	t.Helper()
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := 0; i < len(gotLines) && i < len(wantLines); i++ {
+		if gotLines[i] != wantLines[i] {
+			t.Fatalf("line %d:\ngot:  %q\nwant: %q", i+1, gotLines[i], wantLines[i])
+		}
+	}
+	if len(gotLines) != len(wantLines) {
+		t.Fatalf("got %d lines, want %d", len(gotLines), len(wantLines))
+	}
+}
+
+// BenchmarkGenerate writes plans of increasing length. ns/event should stay
+// the same as the plan grows, showing generation is linear, and so should
+// heap-bytes, the most live heap seen while writing, showing memory doesn't
+// grow with the plan.
+func BenchmarkGenerate(b *testing.B) {
+	for _, n := range []int{365, 3650, 36500} {
+		b.Run(fmt.Sprintf("days=%d", n), func(b *testing.B) {
+			g := newTestGenerator(b)
+			sched, err := newSchedule(defaultStartDate, "06:30", 20*time.Minute, "America/Chicago")
+			if err != nil {
+				b.Fatal(err)
+			}
+			g.sched = sched
+			g.rrule = recurrences["none"]
+			var peak uint64
+			sample := func() {
+				var stats runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&stats)
+				if stats.HeapAlloc > peak {
+					peak = stats.HeapAlloc
+				}
+			}
+			progress := func(events int) {
+				if events%1000 == 0 {
+					b.StopTimer()
+					sample()
+					b.StartTimer()
+				}
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				if err := g.writeCalendar(ioutil.Discard, syntheticPlan(n), progress); err != nil {
+					b.Fatal(err)
+				}
+			}
+			elapsed := time.Since(start)
+			b.StopTimer()
+			sample()
+			b.ReportMetric(float64(elapsed.Nanoseconds())/float64(b.N*n), "ns/event")
+			b.ReportMetric(float64(peak), "heap-bytes")
+		})
+	}
+}
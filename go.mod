@@ -3,6 +3,7 @@ module github.com/isaachess/bibleinayear
 go 1.14
 
 require (
+	github.com/BurntSushi/toml v0.3.0
 	github.com/google/uuid v1.1.4
 	storj.io/common v0.0.0-20210112134249-628c5258937b
 )
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// component is a parsed iCalendar component such as VCALENDAR or VEVENT.
+type component struct {
+	name       string
+	properties []*property
+	components []*component
+	// line is the line number of the BEGIN line
+	line int
+}
+
+// property is a single unfolded content line, e.g.
+// DTSTART;VALUE=DATE:20210101.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+	// line is the line number the property starts on
+	line int
+}
+
+// get returns the first property called name, or nil.
+func (c *component) get(name string) *property {
+	for _, p := range c.properties {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// value returns the value of the first property called name, or "".
+func (c *component) value(name string) string {
+	if p := c.get(name); p != nil {
+		return p.value
+	}
+	return ""
+}
+
+// children returns the nested components called name.
+func (c *component) children(name string) []*component {
+	var found []*component
+	for _, child := range c.components {
+		if child.name == name {
+			found = append(found, child)
+		}
+	}
+	return found
+}
+
+// parseICS reads an iCalendar stream and returns its top-level components,
+// normally a single VCALENDAR.
+func parseICS(r io.Reader) ([]*component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var top []*component
+	var stack []*component
+	for _, line := range lines {
+		if line.text == "" {
+			continue
+		}
+		p, err := parseProperty(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %v", line.number, err)
+		}
+		p.line = line.number
+		switch p.name {
+		case "BEGIN":
+			c := &component{name: strings.ToUpper(p.value), line: line.number}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.components = append(parent.components, c)
+			} else {
+				top = append(top, c)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].name != strings.ToUpper(p.value) {
+				return nil, fmt.Errorf("Line %d: unexpected END:%s", line.number, p.value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("Line %d: property %s outside of a component", line.number, p.name)
+			}
+			c := stack[len(stack)-1]
+			c.properties = append(c.properties, p)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("Missing END:%s", stack[len(stack)-1].name)
+	}
+	return top, nil
+}
+
+// contentLine is an unfolded content line and the line number it starts on.
+type contentLine struct {
+	text   string
+	number int
+}
+
+// unfold joins continuation lines, which start with a space or tab, onto the
+// line before them.
+func unfold(r io.Reader) ([]contentLine, error) {
+	var lines []contentLine
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1].text += line[1:]
+			continue
+		}
+		lines = append(lines, contentLine{text: line, number: n})
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line into its name, parameters and value.
+// Colons and semicolons inside quoted parameter values are not separators.
+func parseProperty(line string) (*property, error) {
+	var quoted bool
+	sep := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 {
+		return nil, fmt.Errorf("missing ':' in %q", line)
+	}
+
+	p := &property{value: line[sep+1:]}
+	fields := splitUnquoted(line[:sep], ';')
+	p.name = strings.ToUpper(fields[0])
+	if p.name == "" {
+		return nil, fmt.Errorf("missing property name in %q", line)
+	}
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid parameter %q", field)
+		}
+		if p.params == nil {
+			p.params = make(map[string]string)
+		}
+		p.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return p, nil
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	var fields []string
+	var quoted bool
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == sep && !quoted:
+			fields = append(fields, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(fields, s[start:])
+}
+
+// writeContentLines writes s, whose content lines are separated by "\n",
+// with the CRLF line breaks RFC 5545 requires. Lines of s starting with a
+// space continue the line before. Every line is folded here, so no line
+// written is longer than maxLineLength octets.
+func writeContentLines(w io.Writer, s string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\n ", ""), "\n") {
+		foldLine(&b, line)
+		b.WriteString("\r\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// foldLine writes line to b, breaking it into continuation lines of at most
+// maxLineLength octets without splitting a character.
+func foldLine(b *strings.Builder, line string) {
+	var n int
+	for _, r := range line {
+		if n+utf8.RuneLen(r) > maxLineLength {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += utf8.RuneLen(r)
+	}
+}
+
+// unescapeText reverses the TEXT value escaping of RFC 5545.
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// escapeText applies the TEXT value escaping of RFC 5545.
+var escapeText = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bibleinayear import CALENDAR.ics [PLAN.txt]")
+		fmt.Fprintln(fs.Output(), "Writes the plan to standard output if PLAN.txt is omitted.")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return errors.New("Please provide the calendar to import")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	calendars, err := parseICS(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	days, skipped := calendarDays(calendars)
+	for _, summary := range skipped {
+		log.Printf("Skipping event %q: not a plan day", summary)
+	}
+	if len(days) == 0 {
+		return fmt.Errorf("No plan days found in %s", fs.Arg(0))
+	}
+
+	if fs.NArg() == 1 {
+		return writePlan(os.Stdout, days)
+	}
+	out, err := os.Create(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := writePlan(out, days); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// summaryPattern matches event summaries such as "Day 12: Patriarchs",
+// "Day 12 - Patriarchs" or just "Day 12", in any supported language.
+var summaryPattern = func() *regexp.Regexp {
+	words := make([]string, 0, len(dayWords))
+	for word := range dayWords {
+		words = append(words, regexp.QuoteMeta(word))
+	}
+	sort.Strings(words)
+	return regexp.MustCompile(`^\s*(?:` + strings.Join(words, "|") + `)\s+(\d+)\s*(?:[:\-–—]\s*(.*?))?\s*$`)
+}()
+
+// calendarDays reconstructs plan days from calendar events whose summary
+// names the day and period and whose description lists the readings, with
+// any introduction and notes. The X-BIBLE-OSIS property, if any, wins over
+// readings in the description that don't cover the same passages. Events that don't look like a plan day are
+// returned by summary in skipped. Days without a period stay in the previous
+// day's period.
+func calendarDays(calendars []*component) (days []*day, skipped []string) {
+	for _, cal := range calendars {
+		for _, ev := range cal.children("VEVENT") {
+			summary := unescapeText(ev.value("SUMMARY"))
+			m := summaryPattern.FindStringSubmatch(summary)
+			if m == nil {
+				skipped = append(skipped, summary)
+				continue
+			}
+			number, err := strconv.Atoi(m[1])
+			if err != nil {
+				skipped = append(skipped, summary)
+				continue
+			}
+			d := &day{number: number, period: lookupPeriod(m[2])}
+			readDescription(d, unescapeText(ev.value("DESCRIPTION")))
+			// Descriptions in OSIS style or from other tools may not
+			// read back, but the OSIS references written with every
+			// event do.
+			if ids := strings.Fields(unescapeText(ev.value("X-BIBLE-OSIS"))); len(ids) > 0 {
+				if readings := osisReadings(ids); readings != nil && !sameReadings(readings, d.readings) {
+					d.readings = readings
+				}
+			}
+			joinBooks(d.readings)
+			inferTracks(d.readings)
+			days = append(days, d)
+		}
+	}
+	sort.SliceStable(days, func(i, j int) bool { return days[i].number < days[j].number })
+	for i, d := range days {
+		if d.period == nil && i > 0 {
+			d.period = days[i-1].period
+		}
+		if d.period != nil && d.intro != "" && d.period.describedAs(d.intro) {
+			// Calendars show the description of a period without an
+			// introduction on its first day.
+			d.intro = ""
+		}
+	}
+	return days, skipped
+}
+
+var (
+	lineBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>`)
+	linkPattern      = regexp.MustCompile(`(?is)<a\s[^>]*>.*?</a>`)
+	tagPattern       = regexp.MustCompile(`<[^>]*>`)
+	passagePattern   = regexp.MustCompile(`^\d[\d:.\-–—ab]*$`)
+	paragraphPattern = regexp.MustCompile(`\n\s*\n`)
+)
+
+// readDescription fills in d's readings, introduction and notes from an
+// event description in any of the descriptionStyles, or plain text or HTML
+// from elsewhere. The readings are the first block of paragraphs made only
+// of references, each line maybe after a track label such as "Psalm:". The
+// paragraphs before it are the introduction, and those after it the notes,
+// leaving out links. Lines of OSIS references are read as well. Track
+// labels are dropped, since they may be translated; calendarDays infers the
+// tracks instead.
+func readDescription(d *day, description string) {
+	// Google descriptions put each reference in its own paragraph; plain
+	// text puts them all in one, and may have angle brackets of its own.
+	isHTML := lineBreakPattern.MatchString(description)
+	if isHTML {
+		description = lineBreakPattern.ReplaceAllString(description, "\n")
+		description = linkPattern.ReplaceAllString(description, "")
+		description = html.UnescapeString(tagPattern.ReplaceAllString(description, ""))
+	}
+
+	var paragraphs []string
+	for _, p := range paragraphPattern.Split(description, -1) {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	start := -1
+	for i, p := range paragraphs {
+		if referenceParagraph(p) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return
+	}
+	end := start + 1
+	for isHTML && end < len(paragraphs) && referenceParagraph(paragraphs[end]) {
+		end++
+	}
+
+	d.intro = strings.Join(paragraphs[:start], "\n")
+	for _, p := range paragraphs[start:end] {
+		for _, line := range strings.Split(p, "\n") {
+			line = referenceLine(line)
+			if readings := osisReadings(strings.Fields(line)); readings != nil {
+				d.readings = append(d.readings, readings...)
+				continue
+			}
+			d.readings = append(d.readings, parseReferences(line)...)
+		}
+	}
+	for _, p := range paragraphs[end:] {
+		if !strings.Contains(p, "://") {
+			d.notes = append(d.notes, strings.Split(p, "\n")...)
+		}
+	}
+}
+
+// joinBooks marks whole books following each other in the canon as joined,
+// as in "2 John, 3 John", since descriptions don't keep the commas.
+func joinBooks(readings []*reading) {
+	for i, r := range readings {
+		if i > 0 && len(readings[i-1].passages) == 0 && followsInCanon(readings[i-1].book, r.book) {
+			r.joined = true
+		}
+	}
+}
+
+// followsInCanon reports whether book comes right after prev in the canon.
+func followsInCanon(prev, book string) bool {
+	i := canonIndex(book)
+	return i > 0 && canon[i-1] == lookupCanon(prev)
+}
+
+// referenceParagraph reports whether every line of p is a list of
+// references.
+func referenceParagraph(p string) bool {
+	for _, line := range strings.Split(p, "\n") {
+		if !isReferences(referenceLine(line)) {
+			return false
+		}
+	}
+	return true
+}
+
+// referenceLine drops a track label, such as "Psalm: ", from the start of a
+// line of references.
+func referenceLine(line string) string {
+	line = strings.TrimSpace(line)
+	if i := strings.Index(line, ": "); i >= 0 && isReferences(line[i+2:]) {
+		return line[i+2:]
+	}
+	return line
+}
+
+// isReferences reports whether s is made only of book names and passages,
+// starting with a book, as in "Genesis 1-2, Psalm 19", or of OSIS
+// references.
+func isReferences(s string) bool {
+	tokens := tokenizeReferences(s)
+	if len(tokens) == 0 {
+		return false
+	}
+	if osisReadings(strings.Fields(s)) != nil {
+		return true
+	}
+	for i := 0; i < len(tokens); {
+		if _, n := matchBook(tokens[i:]); n > 0 {
+			i += n
+			continue
+		}
+		if i == 0 || !passagePattern.MatchString(tokens[i].text) {
+			return false
+		}
+		i++
+	}
+	return true
+}
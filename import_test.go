@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// readPlanSource parses plan.
+func readPlanSource(t *testing.T, plan planSource) []*day {
+	t.Helper()
+	r, err := plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	days, err := parsePlan(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return days
+}
+
+// importCalendar generates a calendar from plan with g and imports it
+// again.
+func importCalendar(t *testing.T, g *generator, plan planSource) []*day {
+	t.Helper()
+	var cal bytes.Buffer
+	if err := g.writeCalendar(&cal, plan, nil); err != nil {
+		t.Fatal(err)
+	}
+	cals, err := parseICS(&cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	days, skipped := calendarDays(cals)
+	if len(skipped) > 0 {
+		t.Errorf("skipped %q", skipped)
+	}
+	return days
+}
+
+// importPlan generates a calendar from plan with g and imports it again,
+// returning both versions written out as plans.
+func importPlan(t *testing.T, g *generator, plan planSource) (want, got string) {
+	t.Helper()
+	var before, after bytes.Buffer
+	if err := writePlan(&before, readPlanSource(t, plan)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePlan(&after, importCalendar(t, g, plan)); err != nil {
+		t.Fatal(err)
+	}
+	return before.String(), after.String()
+}
+
+// TestImportRoundTrip checks that a plan with introductions and notes,
+// some starting with book names, reads back the same from a calendar in
+// every description style.
+func TestImportRoundTrip(t *testing.T) {
+	notes, err := ioutil.ReadFile("testdata/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plans := map[string]string{
+		"notes": string(notes),
+		"book names in notes": "Early World\n> Genesis tells of creation.\n" +
+			"Day 1 Genesis 1-2 Psalm 19\n> John 1 echoes this: read it too.\n" +
+			"Day 2 Genesis 3-4 Psalm 104\n",
+		"markup in text": "Early World\n> Read <Genesis> & rest.\n" +
+			"Day 1 Genesis 1-2 Psalm 19\n> Compare <b>this</b> with 1 < 2.\n",
+	}
+	for name, plan := range plans {
+		plan := plan
+		for style, describe := range descriptionStyles {
+			t.Run(name+"/"+style, func(t *testing.T) {
+				g := newTestGenerator(t)
+				g.describe = describe
+				want, got := importPlan(t, g, func() (io.ReadCloser, error) {
+					return ioutil.NopCloser(strings.NewReader(plan)), nil
+				})
+				if got != want {
+					t.Errorf("imported\n%s\nwant\n%s", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestImportPlanFile(t *testing.T) {
+	if _, err := os.Stat("plan.txt"); err != nil {
+		t.Skip(err)
+	}
+	want, got := importPlan(t, newTestGenerator(t), planFile("plan.txt"))
+	if got != want {
+		t.Error("plan.txt doesn't read back the same from its calendar")
+	}
+}
+
+func TestSummaryPattern(t *testing.T) {
+	tests := []struct {
+		summary, number, period string
+	}{
+		{"Day 12: Patriarchs", "12", "Patriarchs"},
+		{"Day 12 - Patriarchs", "12", "Patriarchs"},
+		{"Day 12 – Patriarchs", "12", "Patriarchs"},
+		{"  Day 12  ", "12", ""},
+		{"Jour 12 : Patriarches", "12", "Patriarches"},
+		{"Día 3: Mundo primitivo", "3", "Mundo primitivo"},
+		{"Staff meeting", "", ""},
+		{"Day twelve", "", ""},
+		{"Holiday 12", "", ""},
+	}
+	for _, tt := range tests {
+		m := summaryPattern.FindStringSubmatch(tt.summary)
+		if tt.number == "" {
+			if m != nil {
+				t.Errorf("%q matched %q", tt.summary, m)
+			}
+			continue
+		}
+		if m == nil || m[1] != tt.number || m[2] != tt.period {
+			t.Errorf("%q matched %q, want day %s, period %q", tt.summary, m, tt.number, tt.period)
+		}
+	}
+}
+
+// TestCalendarDays reads days from events in several summary styles, in any
+// order, among events that aren't plan days.
+func TestCalendarDays(t *testing.T) {
+	event := func(summary, description string) string {
+		return "BEGIN:VEVENT\r\nSUMMARY:" + escapeText(summary) + "\r\nDESCRIPTION:" + escapeText(description) + "\r\nEND:VEVENT\r\n"
+	}
+	cal := "BEGIN:VCALENDAR\r\n" +
+		event("Day 3", "Genesis 5") +
+		event("Staff meeting", "Genesis 1") +
+		event("Day 1: Early World", "Genesis 1-2\nPsalm 19") +
+		event("Jour 4 : Patriarches", "Genèse 12") +
+		event("Day 2 - Early World", "Genesis 3-4") +
+		event("Day 5", "Genesis 13") +
+		"END:VCALENDAR\r\n"
+	cals, err := parseICS(strings.NewReader(cal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	days, skipped := calendarDays(cals)
+	if len(skipped) != 1 || skipped[0] != "Staff meeting" {
+		t.Errorf("skipped %q, want just the staff meeting", skipped)
+	}
+	want := []struct {
+		number   int
+		period   string
+		readings string
+	}{
+		{1, "early-world", "Genesis 1-2; Psalm 19"},
+		{2, "early-world", "Genesis 3-4"},
+		{3, "early-world", "Genesis 5"},
+		{4, "patriarchs", "Genesis 12"},
+		{5, "patriarchs", "Genesis 13"},
+	}
+	if len(days) != len(want) {
+		t.Fatalf("got %d days, want %d", len(days), len(want))
+	}
+	for i, w := range want {
+		d := days[i]
+		var period string
+		if d.period != nil {
+			period = d.period.id
+		}
+		if d.number != w.number || period != w.period || readingsText(d.readings) != w.readings {
+			t.Errorf("got day %d in %q reading %q, want day %d in %q reading %q", d.number, period, readingsText(d.readings), w.number, w.period, w.readings)
+		}
+	}
+}
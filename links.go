@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// linkProvider returns the URL for reading passages in a translation.
+type linkProvider func(readings []*reading, translation string) string
+
+// linkProviders are the sites readings can link to by name. Any other site
+// can be given as a URL template; see templateLinks.
+var linkProviders = map[string]linkProvider{
+	"biblegateway": bibleGatewayLinks,
+}
+
+func lookupLinkProvider(name string) (linkProvider, error) {
+	if provider, ok := linkProviders[name]; ok {
+		return provider, nil
+	}
+	if strings.Contains(name, "{passage}") {
+		return templateLinks(name), nil
+	}
+	names := make([]string, 0, len(linkProviders))
+	for n := range linkProviders {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("Unknown link provider %q: expected one of %s, or a URL containing {passage}", name, strings.Join(names, ", "))
+}
+
+func bibleGatewayLinks(readings []*reading, translation string) string {
+	return generateBibleGatewayLink(readings, translation)
+}
+
+// templateLinks links to a site by replacing {passage} and {translation} in
+// template, e.g. "https://example.com/read?q={passage}&v={translation}".
+func templateLinks(template string) linkProvider {
+	return func(readings []*reading, translation string) string {
+		return strings.NewReplacer(
+			"{passage}", url.QueryEscape(readingsText(readings)),
+			"{translation}", url.QueryEscape(translation),
+		).Replace(template)
+	}
+}
+
+// readingFlags are the flags of every command that shows readings: the
+// calendar's name, the translations and site readings link to, and how
+// references are written. They are defined once so the calendar, digest
+// and notify commands, and their config files, treat them alike.
+type readingFlags struct {
+	name, translations, links, references *string
+	enDash                                *bool
+}
+
+func addReadingFlags(fs *flag.FlagSet) *readingFlags {
+	return &readingFlags{
+		name:         fs.String("name", "", "calendar name (defaults to the language's name for it)"),
+		translations: fs.String("translations", "", "comma-separated translations to link to (defaults to the language's)"),
+		links:        fs.String("links", "biblegateway", "site to link readings to: biblegateway, or a URL with {passage} and {translation}"),
+		references:   fs.String("references", "full", "how readings are written: full (1 Samuel 3:1-10), abbreviated (1 Sam 3:1-10) or osis (1Sam.3.1-1Sam.3.10)"),
+		enDash:       fs.Bool("en-dash", false, "write ranges in readings with an en dash (3:1–10)"),
+	}
+}
+
+// readingOptions are the readingFlags as given, or the defaults for a
+// language.
+type readingOptions struct {
+	name         string
+	translations []string
+	links        linkProvider
+	refs         referenceFormat
+}
+
+func (f *readingFlags) options(l *locale) (*readingOptions, error) {
+	refs, err := newReferenceFormat(*f.references, *f.enDash)
+	if err != nil {
+		return nil, err
+	}
+	links, err := lookupLinkProvider(*f.links)
+	if err != nil {
+		return nil, err
+	}
+	o := &readingOptions{name: l.message("calendar"), translations: l.translations, links: links, refs: refs}
+	if *f.name != "" {
+		o.name = *f.name
+	}
+	if *f.translations != "" {
+		o.translations = splitList(*f.translations)
+	}
+	return o, nil
+}
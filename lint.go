@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bibleinayear lint CALENDAR.ics...")
+		fmt.Fprintln(fs.Output(), "Reports where the calendars break the rules of RFC 5545.")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("Please provide the calendars to check")
+	}
+
+	var problems int
+	for _, path := range fs.Args() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, v := range lintICS(data) {
+			if v.line > 0 {
+				fmt.Printf("%s:%d: %s\n", path, v.line, v.message)
+			} else {
+				fmt.Printf("%s: %s\n", path, v.message)
+			}
+			problems++
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d problems found", problems)
+	}
+	return nil
+}
+
+// violation is a broken rule, at a line of the file if known.
+type violation struct {
+	line    int
+	message string
+}
+
+// maxLineLength is the longest a content line may be, in octets, not
+// counting the line break.
+const maxLineLength = 75
+
+// lintICS checks an iCalendar file against RFC 5545.
+func lintICS(data []byte) []violation {
+	var vs []violation
+	lines := bytes.Split(data, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	var bare []int
+	for i, line := range lines {
+		if !bytes.HasSuffix(line, []byte("\r")) {
+			bare = append(bare, i+1)
+		}
+		if n := len(bytes.TrimSuffix(line, []byte("\r"))); n > maxLineLength {
+			vs = append(vs, violation{i + 1, fmt.Sprintf("line is %d octets long; fold lines longer than %d", n, maxLineLength)})
+		}
+	}
+	// One report is enough when a whole file uses the wrong line breaks.
+	switch {
+	case len(bare) == 1:
+		vs = append(vs, violation{bare[0], "line ends with LF instead of CRLF"})
+	case len(bare) > 1:
+		vs = append(vs, violation{bare[0], fmt.Sprintf("line ends with LF instead of CRLF, as do %d more", len(bare)-1)})
+	}
+
+	calendars, err := parseICS(bytes.NewReader(data))
+	if err != nil {
+		return append(vs, violation{message: err.Error()})
+	}
+	if len(calendars) == 0 {
+		return append(vs, violation{message: "no VCALENDAR found"})
+	}
+	for _, cal := range calendars {
+		if cal.name != "VCALENDAR" {
+			vs = append(vs, violation{cal.line, fmt.Sprintf("%s found outside of a VCALENDAR", cal.name)})
+			continue
+		}
+		vs = append(vs, lintCalendar(cal)...)
+	}
+	sort.SliceStable(vs, func(i, j int) bool { return vs[i].line < vs[j].line })
+	return vs
+}
+
+// requiredProperties must appear in each component.
+var requiredProperties = map[string][]string{
+	"VCALENDAR": {"PRODID", "VERSION"},
+	"VEVENT":    {"UID", "DTSTAMP", "DTSTART"},
+	"VTIMEZONE": {"TZID"},
+	"STANDARD":  {"DTSTART", "TZOFFSETFROM", "TZOFFSETTO"},
+	"DAYLIGHT":  {"DTSTART", "TZOFFSETFROM", "TZOFFSETTO"},
+	"VALARM":    {"ACTION", "TRIGGER"},
+}
+
+// alarmProperties must appear in alarms with each action.
+var alarmProperties = map[string][]string{
+	"AUDIO":   nil,
+	"DISPLAY": {"DESCRIPTION"},
+	"EMAIL":   {"DESCRIPTION", "SUMMARY", "ATTENDEE"},
+}
+
+// singleProperties may appear at most once in each component.
+var singleProperties = map[string][]string{
+	"VCALENDAR": {"PRODID", "VERSION", "CALSCALE", "METHOD"},
+	"VEVENT": {"UID", "DTSTAMP", "DTSTART", "DTEND", "DURATION", "SEQUENCE", "STATUS",
+		"SUMMARY", "DESCRIPTION", "LOCATION", "TRANSP", "LAST-MODIFIED", "URL", "COLOR"},
+	"VALARM": {"ACTION", "TRIGGER", "DESCRIPTION", "SUMMARY"},
+}
+
+// textProperties have TEXT values, which must escape backslashes,
+// semicolons and commas. In list properties commas separate the values.
+var textProperties = map[string]bool{
+	"SUMMARY":     false,
+	"DESCRIPTION": false,
+	"LOCATION":    false,
+	"COMMENT":     false,
+	"X-ALT-DESC":  false,
+	"CATEGORIES":  true,
+	"RESOURCES":   true,
+}
+
+func lintCalendar(cal *component) []violation {
+	vs := lintComponent(cal)
+	if v := cal.get("VERSION"); v != nil && v.value != "2.0" {
+		vs = append(vs, violation{v.line, fmt.Sprintf("VERSION is %q, want 2.0", v.value)})
+	}
+	seen := make(map[string]int)
+	for _, ev := range cal.children("VEVENT") {
+		uid := ev.get("UID")
+		if uid == nil {
+			continue
+		}
+		key := uid.value + "\x00" + ev.value("RECURRENCE-ID")
+		if first, ok := seen[key]; ok {
+			vs = append(vs, violation{uid.line, fmt.Sprintf("UID %s is already used by the event on line %d", uid.value, first)})
+			continue
+		}
+		seen[key] = ev.line
+	}
+	return vs
+}
+
+// lintComponent checks c and the components inside it.
+func lintComponent(c *component) []violation {
+	var vs []violation
+	for _, name := range requiredProperties[c.name] {
+		if c.get(name) == nil {
+			vs = append(vs, violation{c.line, fmt.Sprintf("%s has no %s", c.name, name)})
+		}
+	}
+	for _, name := range singleProperties[c.name] {
+		var count int
+		for _, p := range c.properties {
+			if p.name == name {
+				if count++; count == 2 {
+					vs = append(vs, violation{p.line, fmt.Sprintf("%s has more than one %s", c.name, name)})
+				}
+			}
+		}
+	}
+	for _, p := range c.properties {
+		if list, ok := textProperties[p.name]; ok {
+			if msg := lintText(p.value, list); msg != "" {
+				vs = append(vs, violation{p.line, p.name + ": " + msg})
+			}
+		}
+		if p.name == "RRULE" {
+			if msg := lintRecurrence(p.value); msg != "" {
+				vs = append(vs, violation{p.line, "RRULE: " + msg})
+			}
+		}
+	}
+	switch c.name {
+	case "VEVENT":
+		vs = append(vs, lintEventTimes(c)...)
+	case "VALARM":
+		action := strings.ToUpper(c.value("ACTION"))
+		needed, ok := alarmProperties[action]
+		if !ok && action != "" && !strings.HasPrefix(action, "X-") {
+			vs = append(vs, violation{c.get("ACTION").line, fmt.Sprintf("unknown alarm action %q", action)})
+		}
+		for _, name := range needed {
+			if c.get(name) == nil {
+				vs = append(vs, violation{c.line, fmt.Sprintf("%s alarm has no %s", action, name)})
+			}
+		}
+	}
+	for _, child := range c.components {
+		vs = append(vs, lintComponent(child)...)
+	}
+	return vs
+}
+
+// lintText checks the escaping of a TEXT value. It returns a description of
+// the first problem, or "".
+func lintText(value string, list bool) string {
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if i == len(value)-1 {
+				return "value ends with a lone backslash"
+			}
+			i++
+			if !strings.ContainsRune(`\;,nN`, rune(value[i])) {
+				return fmt.Sprintf(`invalid escape \%c`, value[i])
+			}
+		case ';':
+			return "unescaped ';'"
+		case ',':
+			if !list {
+				return "unescaped ','"
+			}
+		}
+	}
+	return ""
+}
+
+// lintEventTimes checks that an event ends after it starts.
+func lintEventTimes(ev *component) []violation {
+	start, end := ev.get("DTSTART"), ev.get("DTEND")
+	if start == nil {
+		return nil
+	}
+	var vs []violation
+	begin, err := parseTime(start)
+	if err != nil {
+		return append(vs, violation{start.line, "DTSTART: " + err.Error()})
+	}
+	if end != nil && ev.get("DURATION") != nil {
+		vs = append(vs, violation{end.line, "VEVENT has both DTEND and DURATION"})
+	}
+	if end == nil {
+		return vs
+	}
+	finish, err := parseTime(end)
+	if err != nil {
+		return append(vs, violation{end.line, "DTEND: " + err.Error()})
+	}
+	if strings.ToUpper(start.params["VALUE"]) != strings.ToUpper(end.params["VALUE"]) {
+		return append(vs, violation{end.line, "DTSTART and DTEND have different value types"})
+	}
+	if !finish.After(begin) {
+		vs = append(vs, violation{end.line, fmt.Sprintf("DTEND %s is not after DTSTART %s", end.value, start.value)})
+	}
+	return vs
+}
+
+// parseTime parses a DATE or DATE-TIME property. Times in a time zone that
+// can't be loaded are compared as if they were UTC.
+func parseTime(p *property) (time.Time, error) {
+	if strings.ToUpper(p.params["VALUE"]) == "DATE" {
+		t, err := time.Parse("20060102", p.value)
+		if err != nil {
+			return t, fmt.Errorf("invalid date %q", p.value)
+		}
+		return t, nil
+	}
+	loc := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	layout := "20060102T150405"
+	if strings.HasSuffix(p.value, "Z") {
+		layout += "Z"
+	}
+	t, err := time.ParseInLocation(layout, p.value, loc)
+	if err != nil {
+		return t, fmt.Errorf("invalid date-time %q", p.value)
+	}
+	return t, nil
+}
+
+// recurrenceParts are the RRULE parts and the range of each number in them.
+// BYDAY and WKST are checked separately.
+var recurrenceParts = map[string][2]int{
+	"BYSECOND":   {0, 60},
+	"BYMINUTE":   {0, 59},
+	"BYHOUR":     {0, 23},
+	"BYMONTHDAY": {-31, 31},
+	"BYYEARDAY":  {-366, 366},
+	"BYWEEKNO":   {-53, 53},
+	"BYMONTH":    {1, 12},
+	"BYSETPOS":   {-366, 366},
+}
+
+var (
+	frequencies = map[string]bool{"SECONDLY": true, "MINUTELY": true, "HOURLY": true, "DAILY": true, "WEEKLY": true, "MONTHLY": true, "YEARLY": true}
+	weekdays    = map[string]bool{"MO": true, "TU": true, "WE": true, "TH": true, "FR": true, "SA": true, "SU": true}
+)
+
+// lintRecurrence checks an RRULE value. It returns a description of the
+// first problem, or "".
+func lintRecurrence(value string) string {
+	parts := make(map[string]string)
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Sprintf("invalid part %q", part)
+		}
+		name := strings.ToUpper(kv[0])
+		if _, ok := parts[name]; ok {
+			return fmt.Sprintf("%s given more than once", name)
+		}
+		parts[name] = kv[1]
+	}
+	freq, ok := parts["FREQ"]
+	if !ok {
+		return "missing FREQ"
+	}
+	if !frequencies[strings.ToUpper(freq)] {
+		return fmt.Sprintf("unknown FREQ %q", freq)
+	}
+	if _, ok := parts["COUNT"]; ok {
+		if _, ok := parts["UNTIL"]; ok {
+			return "COUNT and UNTIL can't both be given"
+		}
+	}
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := parts[name]
+		switch name {
+		case "FREQ":
+		case "COUNT", "INTERVAL":
+			if n, err := strconv.Atoi(v); err != nil || n < 1 {
+				return fmt.Sprintf("%s must be a positive number, not %q", name, v)
+			}
+		case "UNTIL":
+			if _, err := parseTime(&property{value: v, params: untilParams(v)}); err != nil {
+				return "UNTIL: " + err.Error()
+			}
+		case "WKST":
+			if !weekdays[strings.ToUpper(v)] {
+				return fmt.Sprintf("invalid WKST %q", v)
+			}
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				d = strings.ToUpper(d)
+				if len(d) < 2 || !weekdays[d[len(d)-2:]] {
+					return fmt.Sprintf("invalid BYDAY %q", d)
+				}
+				if n := d[:len(d)-2]; n != "" {
+					if i, err := strconv.Atoi(n); err != nil || i == 0 || i < -53 || i > 53 {
+						return fmt.Sprintf("invalid BYDAY %q", d)
+					}
+				}
+			}
+		default:
+			limits, ok := recurrenceParts[name]
+			if !ok {
+				if strings.HasPrefix(name, "X-") {
+					continue
+				}
+				return fmt.Sprintf("unknown part %s", name)
+			}
+			for _, s := range strings.Split(v, ",") {
+				n, err := strconv.Atoi(s)
+				if err != nil || n < limits[0] || n > limits[1] || (n == 0 && limits[0] < 0) {
+					return fmt.Sprintf("invalid %s %q", name, s)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// untilParams gives UNTIL values without a time the DATE value type.
+func untilParams(until string) map[string]string {
+	if !strings.Contains(until, "T") {
+		return map[string]string{"VALUE": "DATE"}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLintGolden(t *testing.T) {
+	paths, err := filepath.Glob("testdata/*.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range lintICS(data) {
+			t.Errorf("%s:%d: %s", path, v.line, v.message)
+		}
+	}
+}
+
+// TestLintGenerated lints calendars generated with the settings most likely
+// to produce long lines: link templates, long summaries, podcast episodes,
+// merged cohorts and email alarms with accented text.
+func TestLintGenerated(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, g *generator)
+	}{
+		{
+			name: "link template",
+			setup: func(t *testing.T, g *generator) {
+				g.links = templateLinks("https://example.com/bible/read?passage={passage}&translation={translation}&source=calendar")
+			},
+		},
+		{
+			name: "summary template",
+			setup: func(t *testing.T, g *generator) {
+				var err error
+				g.templates, err = newEventTemplates(strings.Repeat("{{.Period}}, day {{.Day}}: {{join .Readings \"; \"}} ", 3), "", "")
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "media",
+			setup: func(t *testing.T, g *generator) {
+				g.episodes = &media{
+					template: "https://example.com/podcasts/bible-in-a-year/season-one/episodes/day-{day:3}.mp3",
+					mimeType: "audio/mpeg",
+					episodes: map[int]*episode{1: {title: "Creation, and the Fall; a very long episode title indeed", duration: 21 * time.Minute}},
+				}
+			},
+		},
+		{
+			name: "merged cohort",
+			setup: func(t *testing.T, g *generator) {
+				g.cohort = "young-adults"
+				g.prefix = "[Young adults and families of St. Mary's parish, Tuesday group] "
+				g.category = "Young adults and families of St. Mary's parish, Tuesday group"
+				g.color = "darkolivegreen"
+			},
+		},
+	}
+	for _, lang := range []string{"pt", "fr"} {
+		lang := lang
+		tests = append(tests, struct {
+			name  string
+			setup func(t *testing.T, g *generator)
+		}{
+			name: lang + " email alarm",
+			setup: func(t *testing.T, g *generator) {
+				var err error
+				g.locale = locales[lang]
+				g.translations = g.locale.translations
+				if g.alarm, err = newAlarm("06:00", "email", "reader@example.com"); err != nil {
+					t.Fatal(err)
+				}
+			},
+		})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGenerator(t)
+			tt.setup(t, g)
+			var buf bytes.Buffer
+			if err := writeMerged(&buf, g.name, []*generator{g}, planFile("plan.txt"), nil); err != nil {
+				t.Fatal(err)
+			}
+			violations := lintICS(buf.Bytes())
+			for i, v := range violations {
+				if i == 5 {
+					t.Errorf("and %d more", len(violations)-i)
+					break
+				}
+				t.Errorf("%d: %s", v.line, v.message)
+			}
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	event := func(lines ...string) string {
+		return strings.Join(append(append([]string{
+			"BEGIN:VCALENDAR",
+			"PRODID:-//Example//EN",
+			"VERSION:2.0",
+			"BEGIN:VEVENT",
+			"UID:1",
+			"DTSTAMP:20210101T000000Z",
+		}, lines...), "END:VEVENT", "END:VCALENDAR", ""), "\r\n")
+	}
+	tests := []struct {
+		name string
+		ics  string
+		want []string
+	}{
+		{
+			name: "valid",
+			ics:  event("DTSTART;VALUE=DATE:20210101", "DTEND;VALUE=DATE:20210102", "RRULE:FREQ=YEARLY", `SUMMARY:Day 1\, again`),
+		},
+		{
+			name: "missing properties",
+			ics:  "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			want: []string{
+				"1: VCALENDAR has no PRODID",
+				"1: VCALENDAR has no VERSION",
+				"2: VEVENT has no UID",
+				"2: VEVENT has no DTSTAMP",
+				"2: VEVENT has no DTSTART",
+			},
+		},
+		{
+			name: "LF line breaks",
+			ics:  strings.ReplaceAll(event("DTSTART:20210101T060000Z"), "\r\n", "\n"),
+			want: []string{"1: line ends with LF instead of CRLF, as do 8 more"},
+		},
+		{
+			name: "long line",
+			ics:  event("DTSTART:20210101T060000Z", "SUMMARY:"+strings.Repeat("x", 70)),
+			want: []string{"8: line is 78 octets long; fold lines longer than 75"},
+		},
+		{
+			name: "escaping",
+			ics:  event("DTSTART:20210101T060000Z", "SUMMARY:a;b", `DESCRIPTION:a\tb`, "CATEGORIES:a,b"),
+			want: []string{`8: SUMMARY: unescaped ';'`, `9: DESCRIPTION: invalid escape \t`},
+		},
+		{
+			name: "end before start",
+			ics:  event("DTSTART;TZID=America/Chicago:20210101T060000", "DTEND:20210101T113000Z"),
+			want: []string{"8: DTEND 20210101T113000Z is not after DTSTART 20210101T060000"},
+		},
+		{
+			name: "duplicate UID",
+			ics: strings.Replace(event("DTSTART:20210101T060000Z"), "END:VEVENT\r\n",
+				"END:VEVENT\r\nBEGIN:VEVENT\r\nUID:1\r\nDTSTAMP:20210101T000000Z\r\nDTSTART:20210101T060000Z\r\nEND:VEVENT\r\n", 1),
+			want: []string{"10: UID 1 is already used by the event on line 4"},
+		},
+		{
+			name: "bad RRULE",
+			ics:  event("DTSTART:20210101T060000Z", "RRULE:FREQ=YEARLY;BYMONTH=13"),
+			want: []string{`8: RRULE: invalid BYMONTH "13"`},
+		},
+		{
+			name: "email alarm",
+			ics:  event("DTSTART:20210101T060000Z", "BEGIN:VALARM", "ACTION:EMAIL", "TRIGGER:PT0S", "DESCRIPTION:x", "END:VALARM"),
+			want: []string{"8: EMAIL alarm has no SUMMARY", "8: EMAIL alarm has no ATTENDEE"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range lintICS([]byte(tt.ics)) {
+				got = append(got, fmt.Sprintf("%d: %s", v.line, v.message))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLintRecurrence(t *testing.T) {
+	tests := map[string]string{
+		"FREQ=YEARLY":                        "",
+		"FREQ=YEARLY;BYMONTH=3;BYDAY=2SU":    "",
+		"FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU":  "",
+		"FREQ=WEEKLY;UNTIL=20211231T000000Z": "",
+		"BYMONTH=3":                          "missing FREQ",
+		"FREQ=FORTNIGHTLY":                   `unknown FREQ "FORTNIGHTLY"`,
+		"FREQ=DAILY;COUNT=0":                 `COUNT must be a positive number, not "0"`,
+		"FREQ=DAILY;COUNT=2;UNTIL=20210101":  "COUNT and UNTIL can't both be given",
+		"FREQ=MONTHLY;BYDAY=0MO":             `invalid BYDAY "0MO"`,
+		"FREQ=MONTHLY;BYMONTHDAY=0":          `invalid BYMONTHDAY "0"`,
+		"FREQ=MONTHLY;FREQ=DAILY":            "FREQ given more than once",
+		"FREQ=MONTHLY;BYFORTNIGHT=1":         "unknown part BYFORTNIGHT",
+	}
+	for rule, want := range tests {
+		if got := lintRecurrence(rule); got != want {
+			t.Errorf("lintRecurrence(%q) = %q, want %q", rule, got, want)
+		}
+	}
+}
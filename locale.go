@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// locale holds the messages and book names used to render a calendar in one
+// language. Readings always keep their English book name internally, since
+// that is what BibleGateway links need, and are translated on output.
+type locale struct {
+	tag          string
+	messages     map[string]string
+	books        map[string]string
+	translations []string
+}
+
+// bookAliases are alternative English names that share a translation with
+// another book.
+var bookAliases = map[string]string{
+	"Song of Solomon":      "Song of Songs",
+	"Acts of the Apostles": "Acts",
+}
+
+// primaryBook returns the name an English alias stands for, or name itself.
+func primaryBook(name string) string {
+	if primary, ok := bookAliases[name]; ok {
+		return primary
+	}
+	return name
+}
+
+var locales = map[string]*locale{
+	"en": {
+		tag: "en",
+		messages: map[string]string{
+			"calendar":   "Bible in a Year",
+			"day":        "Day",
+			"listen":     "Listen",
+			"read":       "Read",
+			"summary":    "Day %d: %s",
+			"narrative":  "Narrative",
+			"supporting": "Supporting",
+			"psalm":      "Psalm",
+			"wisdom":     "Wisdom",
+		},
+		translations: translations,
+	},
+	"es": {
+		tag: "es",
+		messages: map[string]string{
+			"calendar":   "La Biblia en un año",
+			"day":        "Día",
+			"listen":     "Escuchar",
+			"read":       "Leer",
+			"summary":    "Día %d: %s",
+			"narrative":  "Narrativa",
+			"supporting": "Complementaria",
+			"psalm":      "Salmo",
+			"wisdom":     "Sabiduría",
+		},
+		books:        spanishBooks,
+		translations: []string{"BLPH", "DHH"},
+	},
+	"pt": {
+		tag: "pt",
+		messages: map[string]string{
+			"calendar":   "A Bíblia em um ano",
+			"day":        "Dia",
+			"listen":     "Ouvir",
+			"read":       "Ler",
+			"summary":    "Dia %d: %s",
+			"narrative":  "Narrativa",
+			"supporting": "Complementar",
+			"psalm":      "Salmo",
+			"wisdom":     "Sabedoria",
+		},
+		books:        portugueseBooks,
+		translations: []string{"NVI-PT", "ARC"},
+	},
+	"pl": {
+		tag: "pl",
+		messages: map[string]string{
+			"calendar":   "Biblia w rok",
+			"day":        "Dzień",
+			"listen":     "Posłuchaj",
+			"read":       "Czytaj",
+			"summary":    "Dzień %d: %s",
+			"narrative":  "Narracja",
+			"supporting": "Uzupełnienie",
+			"psalm":      "Psalm",
+			"wisdom":     "Mądrość",
+		},
+		books:        polishBooks,
+		translations: []string{"SZ-PL", "UBG"},
+	},
+	"fr": {
+		tag: "fr",
+		messages: map[string]string{
+			"calendar":   "La Bible en un an",
+			"day":        "Jour",
+			"listen":     "Écouter",
+			"read":       "Lire",
+			"summary":    "Jour %d : %s",
+			"narrative":  "Récit",
+			"supporting": "Complément",
+			"psalm":      "Psaume",
+			"wisdom":     "Sagesse",
+		},
+		books:        frenchBooks,
+		translations: []string{"BDS", "SG21"},
+	},
+	"tl": {
+		tag: "tl",
+		messages: map[string]string{
+			"calendar":   "Ang Bibliya sa Isang Taon",
+			"day":        "Araw",
+			"listen":     "Pakinggan",
+			"read":       "Basahin",
+			"summary":    "Araw %d: %s",
+			"narrative":  "Salaysay",
+			"supporting": "Karagdagan",
+			"psalm":      "Salmo",
+			"wisdom":     "Karunungan",
+		},
+		books:        tagalogBooks,
+		translations: []string{"ASND", "MBBTAG"},
+	},
+}
+
+// localizedBooks maps every localized book name to its English name, so plans
+// written in any supported language can be parsed.
+var localizedBooks = func() map[string]string {
+	m := make(map[string]string)
+	for _, l := range locales {
+		for english, localized := range l.books {
+			m[localized] = english
+		}
+	}
+	return m
+}()
+
+// dayWords are the words that start a day line in a plan, in every language.
+var dayWords = func() map[string]struct{} {
+	m := make(map[string]struct{})
+	for _, l := range locales {
+		m[l.messages["day"]] = struct{}{}
+	}
+	return m
+}()
+
+func lookupLocale(tag string) (*locale, error) {
+	if l, ok := locales[strings.ToLower(tag)]; ok {
+		return l, nil
+	}
+	tags := make([]string, 0, len(locales))
+	for t := range locales {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return nil, fmt.Errorf("Unknown language %q: expected one of %s", tag, strings.Join(tags, ", "))
+}
+
+// message returns the message for key, falling back to English.
+func (l *locale) message(key string) string {
+	if m, ok := l.messages[key]; ok {
+		return m
+	}
+	return locales["en"].messages[key]
+}
+
+// book returns the localized name of an English book name.
+func (l *locale) book(name string) string {
+	if l.books == nil {
+		return name
+	}
+	if localized, ok := l.books[primaryBook(name)]; ok {
+		return localized
+	}
+	return name
+}
+
+// lookupBook returns the English name of a book given in English or any
+// supported language, or by its English abbreviation, such as "1 Sam".
+func lookupBook(name string) (string, bool) {
+	if _, ok := books[name]; ok {
+		return name, true
+	}
+	if english, ok := localizedBooks[name]; ok {
+		return english, true
+	}
+	english, ok := canonAbbreviations[name]
+	return english, ok
+}
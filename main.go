@@ -1,14 +1,14 @@
 package main
 
 import (
-	"bufio"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/url"
 	"os"
-	"strconv"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -16,117 +16,110 @@ import (
 type reading struct {
 	book     string
 	passages []string
-}
-
-type bookBoundary struct {
-	start, end int
+	// track is the name of the stream of the plan the reading belongs to,
+	// such as "Narrative" or "Psalm".
+	track string
+	// joined is set when a comma joins the reading to a whole book before it,
+	// as "3 John" in "2 John, 3 John", keeping it in that book's track.
+	joined bool
 }
 
 var translations = []string{"RSVCE", "RSV", "ESV", "NABRE"}
 
-var books = map[string]struct{}{
-	"Genesis":              struct{}{},
-	"Exodus":               struct{}{},
-	"Leviticus":            struct{}{},
-	"Numbers":              struct{}{},
-	"Deuteronomy":          struct{}{},
-	"Joshua":               struct{}{},
-	"Judges":               struct{}{},
-	"Ruth":                 struct{}{},
-	"1 Samuel":             struct{}{},
-	"2 Samuel":             struct{}{},
-	"1 Kings":              struct{}{},
-	"2 Kings":              struct{}{},
-	"1 Chronicles":         struct{}{},
-	"2 Chronicles":         struct{}{},
-	"Ezra":                 struct{}{},
-	"Nehemiah":             struct{}{},
-	"Tobit":                struct{}{},
-	"Judith":               struct{}{},
-	"Esther":               struct{}{},
-	"1 Maccabees":          struct{}{},
-	"2 Maccabees":          struct{}{},
-	"Job":                  struct{}{},
-	"Psalms":               struct{}{},
-	"Psalm":                struct{}{},
-	"Proverbs":             struct{}{},
-	"Ecclesiastes":         struct{}{},
-	"Song of Songs":        struct{}{},
-	"Song of Solomon":      struct{}{},
-	"Wisdom":               struct{}{},
-	"Sirach":               struct{}{},
-	"Isaiah":               struct{}{},
-	"Jeremiah":             struct{}{},
-	"Lamentations":         struct{}{},
-	"Baruch":               struct{}{},
-	"Ezekiel":              struct{}{},
-	"Daniel":               struct{}{},
-	"Hosea":                struct{}{},
-	"Joel":                 struct{}{},
-	"Amos":                 struct{}{},
-	"Obadiah":              struct{}{},
-	"Jonah":                struct{}{},
-	"Micah":                struct{}{},
-	"Nahum":                struct{}{},
-	"Habakkuk":             struct{}{},
-	"Zephaniah":            struct{}{},
-	"Haggai":               struct{}{},
-	"Zechariah":            struct{}{},
-	"Malachi":              struct{}{},
-	"Matthew":              struct{}{},
-	"Mark":                 struct{}{},
-	"Luke":                 struct{}{},
-	"John":                 struct{}{},
-	"Acts":                 struct{}{},
-	"Acts of the Apostles": struct{}{},
-	"Romans":               struct{}{},
-	"1 Corinthians":        struct{}{},
-	"2 Corinthians":        struct{}{},
-	"Galatians":            struct{}{},
-	"Ephesians":            struct{}{},
-	"Philippians":          struct{}{},
-	"Colossians":           struct{}{},
-	"1 Thessalonians":      struct{}{},
-	"2 Thessalonians":      struct{}{},
-	"1 Timothy":            struct{}{},
-	"2 Timothy":            struct{}{},
-	"Titus":                struct{}{},
-	"Philemon":             struct{}{},
-	"Hebrews":              struct{}{},
-	"James":                struct{}{},
-	"1 Peter":              struct{}{},
-	"2 Peter":              struct{}{},
-	"1 John":               struct{}{},
-	"2 John":               struct{}{},
-	"3 John":               struct{}{},
-	"Jude":                 struct{}{},
-	"Revelation":           struct{}{},
-}
+// defaultStartDate is the date of day 1 of the plan.
+var defaultStartDate = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	configPath = flag.String("config", "", "TOML file of settings; flags given on the command line override it")
+
+	startDate       = flag.String("start", defaultStartDate.Format("2006-01-02"), "date of day 1 of the plan")
+	readingSettings = addReadingFlags(flag.CommandLine)
+	recurrence      = flag.String("recurrence", "yearly", "how events repeat: yearly or none")
+
+	alarmAt     = flag.String("alarm", "", "local time of day to send a reminder, e.g. 06:30 (no reminder if empty)")
+	alarmAction = flag.String("alarm-action", "display", "reminder action: display or email")
+	alarmEmail  = flag.String("alarm-email", "", "comma-separated addresses to notify when -alarm-action=email")
+
+	eventAt       = flag.String("at", "", "local time of day for timed events, e.g. 06:30 (all-day events if empty)")
+	eventDuration = flag.Duration("duration", 20*time.Minute, "length of timed events")
+	eventTZ       = flag.String("tz", "", "IANA time zone for timed events, e.g. America/Chicago")
+
+	progressEvery = flag.Int("progress", 0, "log progress every N events (no progress if 0)")
+
+	timestamp = flag.String("timestamp", "", "DTSTAMP to use instead of the current time, for reproducible output")
+	previous  = flag.String("previous", "", "previously generated calendar; changed events get a new SEQUENCE")
+
+	lang = flag.String("lang", "en", "language of the calendar: en, es, pt, pl, fr or tl")
+
+	mediaPath = flag.String("media", "", "file mapping days to companion podcast episodes")
+
+	cohortsPath = flag.String("cohorts", "", "file listing cohorts; writes one calendar per cohort into the output directory")
+	merge       = flag.Bool("merge", false, "with -cohorts, write every cohort into one calendar file")
+
+	summaryTemplate     = flag.String("summary-template", "", "text/template for event summaries, e.g. {{.Period}}: day {{.Day}}")
+	descriptionTemplate = flag.String("description-template", "", "text/template for event descriptions, replacing -description")
+	locationTemplate    = flag.String("location-template", "", "text/template for event locations")
+
+	trackList   = flag.String("tracks", "", "comma-separated tracks to keep, e.g. Psalm,Wisdom (all readings if empty)")
+	trackLabels = flag.Bool("track-labels", false, "show each reading's track before it, e.g. Psalm: Psalm 19")
+
+	bookList      = flag.String("books", "", "comma-separated books or ranges of books to keep, e.g. Matthew-John,Psalms")
+	testamentList = flag.String("testaments", "", "comma-separated testaments to keep: old, new")
+	periodList    = flag.String("periods", "", "comma-separated periods whose days to keep, e.g. Patriarchs")
+	compact       = flag.Bool("compact", false, "with a filter, schedule the days kept one after another instead of leaving gaps")
+
+	descriptionFormat = flag.String("description", "google", "description style: google (HTML), plain, or html (plain text with an X-ALT-DESC alternative)")
+)
 
 const header = `BEGIN:VCALENDAR
 PRODID:-//Google Inc//Google Calendar 70.9054//EN
 VERSION:2.0
 CALSCALE:GREGORIAN
 METHOD:PUBLISH
-X-WR-CALNAME:Bible in a Year`
+X-WR-CALNAME:%s`
 
 const footer = "END:VCALENDAR"
 
 const event = `BEGIN:VEVENT
-DTSTART;VALUE=DATE:%s
-DTEND;VALUE=DATE:%s
-RRULE:FREQ=YEARLY
-DTSTAMP:20210112T151454Z
+DTSTART%s
+DTEND%s%s
+DTSTAMP:%s
 UID:%s
+SEQUENCE:%d
+LAST-MODIFIED:%s
 DESCRIPTION:%s
 STATUS:CONFIRMED
 SUMMARY:%s
-TRANSP:TRANSPARENT
+TRANSP:TRANSPARENT%s
 END:VEVENT`
 
+const displayAlarm = `BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER;RELATED=START:%s
+DESCRIPTION:%s
+END:VALARM`
+
+const emailAlarm = `BEGIN:VALARM
+ACTION:EMAIL
+TRIGGER;RELATED=START:%s
+SUMMARY:%s
+DESCRIPTION:%s%s
+END:VALARM`
+
 // TODO(isaac):
 // - Transp: TRANSPARENT
 
+// commands are the subcommands run instead of generating a calendar when
+// named as the first argument.
+var commands = map[string]func(args []string) error{
+	"diff":   runDiff,
+	"digest": runDigest,
+	"import": runImport,
+	"lint":   runLint,
+	"notify": runNotify,
+	"plan":   runPlan,
+}
+
 func main() {
 	if err := run(); err != nil {
 		log.Fatal(err)
@@ -134,79 +127,173 @@ func main() {
 }
 
 func run() error {
-	if len(os.Args) < 3 {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			return command(os.Args[2:])
+		}
+	}
+
+	flag.Parse()
+	planpath, icalpath := flag.Arg(0), flag.Arg(1)
+	if *configPath != "" {
+		c, err := loadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		if err := c.apply(flag.CommandLine); err != nil {
+			return err
+		}
+		if flag.NArg() < 2 {
+			planpath, icalpath = c.Plan, c.Output
+		}
+	}
+	if planpath == "" || icalpath == "" {
 		return errors.New("Please provide path to plan file")
 	}
-	planpath := os.Args[1]
-	icalpath := os.Args[2]
 
-	planfile, err := os.Open(planpath)
+	l, err := lookupLocale(*lang)
 	if err != nil {
 		return err
 	}
-	defer planfile.Close()
 
-	icalfile, err := os.Create(icalpath)
+	alarm, err := newAlarm(*alarmAt, *alarmAction, *alarmEmail)
 	if err != nil {
 		return err
 	}
-	defer icalfile.Close()
 
-	startDate := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	describe, err := lookupDescriptionStyle(*descriptionFormat)
+	if err != nil {
+		return err
+	}
 
-	w := bufio.NewWriter(icalfile)
-	defer func() { err = w.Flush() }()
+	opts, err := readingSettings.options(l)
+	if err != nil {
+		return err
+	}
 
-	err = writeLine(w, header)
+	templates, err := newEventTemplates(*summaryTemplate, *descriptionTemplate, *locationTemplate)
 	if err != nil {
 		return err
 	}
 
-	// period is the narrative period we're currently in
-	var period string
-	scanner := bufio.NewScanner(planfile)
-	for scanner.Scan() {
-		text := strings.ReplaceAll(scanner.Text(), ",", "")
+	episodes, err := loadMedia(*mediaPath)
+	if err != nil {
+		return err
+	}
 
-		if !strings.HasPrefix(text, "Day") {
-			period = text
-			continue
-		}
+	start, err := time.Parse("2006-01-02", *startDate)
+	if err != nil {
+		return fmt.Errorf("Invalid start date %q: %v", *startDate, err)
+	}
+	sched, err := newSchedule(start, *eventAt, *eventDuration, *eventTZ)
+	if err != nil {
+		return err
+	}
 
-		splits := strings.Split(text, " ")
-		if len(splits) < 4 {
-			return fmt.Errorf("Invalid line: expected at least 4 splits. Got: %s", text)
-		}
+	filter, err := newReadingFilter(splitList(*bookList), splitList(*testamentList), splitList(*trackList), splitList(*periodList), *compact)
+	if err != nil {
+		return err
+	}
+	rrule, ok := recurrences[*recurrence]
+	if !ok {
+		return fmt.Errorf("Unknown recurrence %q: expected yearly or none", *recurrence)
+	}
 
-		readings := convertToReadings(splits[2:])
+	now, err := generationTime(*timestamp)
+	if err != nil {
+		return err
+	}
+	// The plan is read as the calendar is written, but make sure it's there
+	// before creating any output.
+	if _, err := os.Stat(planpath); err != nil {
+		return err
+	}
+	plan := planFile(planpath)
+	var progress progressFunc
+	if *progressEvery > 0 {
+		progress = func(events int) {
+			if events%*progressEvery == 0 {
+				log.Printf("Wrote %d events", events)
+			}
+		}
+	}
 
-		day, err := strconv.Atoi(splits[1])
-		if err != nil {
+	g := &generator{
+		locale:       l,
+		name:         opts.name,
+		translations: opts.translations,
+		refs:         opts.refs,
+		links:        opts.links,
+		sched:        sched,
+		alarm:        alarm,
+		describe:     describe,
+		templates:    templates,
+		episodes:     episodes,
+		rrule:        rrule,
+		filter:       filter,
+		trackLabels:  *trackLabels,
+	}
+	if *cohortsPath == "" {
+		if g.revs, err = loadRevisions(*previous, now); err != nil {
 			return err
 		}
+		return g.writeFile(icalpath, plan, progress)
+	}
 
-		dtstart := formatDate(startDate.AddDate(0, 0, day-1))
-		dtend := formatDate(startDate.AddDate(0, 0, day))
-		uid, ok := uids[day]
-		if !ok {
-			return fmt.Errorf("UID not found for %d", day)
+	// With cohorts, the output and -previous paths are directories holding
+	// one calendar per cohort, unless they are merged into one calendar.
+	cohorts, err := loadCohorts(*cohortsPath)
+	if err != nil {
+		return err
+	}
+	var merged []*generator
+	for _, c := range cohorts {
+		cg := *g
+		cg.cohort = c.id
+		cg.name = fmt.Sprintf("%s (%s)", g.name, c.name)
+		if c.calendarName != "" {
+			cg.name = c.calendarName
 		}
-		if err != nil {
+		if len(c.translations) > 0 {
+			cg.translations = c.translations
+		}
+		if cg.sched, err = newSchedule(c.start, *eventAt, *eventDuration, *eventTZ); err != nil {
 			return err
 		}
-		description := generateDescription(readings)
-		summary := fmt.Sprintf("Day %s: %s", splits[1], period)
-		err = writeLine(w, fmt.Sprintf(event, dtstart, dtend, uid, description, summary))
-		if err != nil {
+		if *merge {
+			cg.prefix = "[" + c.name + "] "
+			cg.category = c.name
+			cg.color = c.color
+			merged = append(merged, &cg)
+			continue
+		}
+		var prev string
+		if *previous != "" {
+			prev = filepath.Join(*previous, c.output)
+		}
+		if cg.revs, err = loadRevisions(prev, now); err != nil {
+			return err
+		}
+		out := filepath.Join(icalpath, c.output)
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
 			return err
 		}
+		if err := cg.writeFile(out, plan, progress); err != nil {
+			return fmt.Errorf("Cohort %s: %v", c.name, err)
+		}
 	}
-
-	err = writeLine(w, footer)
-	if err != nil {
-		return err
+	if *merge {
+		revs, err := loadRevisions(*previous, now)
+		if err != nil {
+			return err
+		}
+		for _, mg := range merged {
+			mg.revs = revs
+		}
+		return writeFile(icalpath, func(w io.Writer) error {
+			return writeMerged(w, g.name, merged, plan, progress)
+		})
 	}
-
 	return nil
 }
 
@@ -224,30 +311,44 @@ func formatDate(t time.Time) string {
 	return fmt.Sprintf("%d%s%s", year, fmt.Sprintf("%02d", month), fmt.Sprintf("%02d", day))
 }
 
-func generateDescription(readings []*reading) string {
+// formatDuration renders d as an RFC 5545 duration such as PT6H30M.
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
 	var s strings.Builder
-	for i, reading := range readings {
-		if i > 0 {
-			s.WriteRune('\n')
-			s.WriteString(" <br><br>")
-		}
-		s.WriteString(reading.book)
-		s.WriteString(" ")
-		s.WriteString(strings.Join(reading.passages, ", "))
+	if d < 0 {
+		s.WriteRune('-')
+		d = -d
 	}
-	for _, translation := range translations {
-		s.WriteRune('\n')
-		s.WriteString(" <br><br>")
-		s.WriteString(fmt.Sprintf(`<a href="%s">%s</a>`, generateBibleGatewayLink(readings, translation), translation))
+	s.WriteString("PT")
+	if h := d / time.Hour; h > 0 {
+		s.WriteString(fmt.Sprintf("%dH", h))
+	}
+	if m := d % time.Hour / time.Minute; m > 0 {
+		s.WriteString(fmt.Sprintf("%dM", m))
+	}
+	if sec := d % time.Minute / time.Second; sec > 0 {
+		s.WriteString(fmt.Sprintf("%dS", sec))
 	}
 	return s.String()
 }
 
+// readingsText lists the readings on a single line, e.g. "Genesis 1-2; Psalm 19".
+func readingsText(readings []*reading) string {
+	parts := make([]string, 0, len(readings))
+	for _, reading := range readings {
+		part := reading.book
+		if len(reading.passages) > 0 {
+			part += " " + strings.Join(reading.passages, ", ")
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
 func generateBibleGatewayLink(readings []*reading, translation string) string {
-	bglink := `https://
- www.biblegateway.com/passage/?search=
- %s
- &version=%s`
+	bglink := "https://www.biblegateway.com/passage/?search=%s&version=%s"
 	var s strings.Builder
 	for i, reading := range readings {
 		if i > 0 {
@@ -259,25 +360,3 @@ func generateBibleGatewayLink(readings []*reading, translation string) string {
 	}
 	return fmt.Sprintf(bglink, s.String(), translation)
 }
-
-func convertToReadings(raw []string) (readings []*reading) {
-	r := &reading{}
-	for i := 0; i < len(raw); {
-		token := raw[i]
-		var foundBook bool
-		for j := 1; j < len(raw)-i; j++ {
-			book := strings.Join(raw[i:i+j], " ")
-			if _, foundBook = books[book]; foundBook {
-				r = &reading{book: book}
-				readings = append(readings, r)
-				i += j // increment correct amount here
-				break
-			}
-		}
-		if !foundBook {
-			r.passages = append(r.passages, token)
-			i++
-		}
-	}
-	return readings
-}
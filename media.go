@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// episode is the companion audio for one day of the plan.
+type episode struct {
+	title    string
+	url      string
+	duration time.Duration
+}
+
+// media maps plan days to their episodes. It is read from a file such as:
+//
+//	url https://example.com/bible-in-a-year/day-{day:3}.mp3
+//	type audio/mpeg
+//	Day 1 | Introduction | 21m
+//	Day 2 | The Fall | 19m30s | https://example.com/special.mp3
+//
+// Episode lines are "Day N | title | duration | url", where everything after
+// the day is optional. {day} in the url template is replaced by the day
+// number, and {day:3} by the day number padded to three digits. If a template
+// is given, days without an episode line still get a link.
+type media struct {
+	template string
+	mimeType string
+	episodes map[int]*episode
+}
+
+var (
+	mediaDayPattern    = regexp.MustCompile(`^Day\s+(\d+)$`)
+	templateDayPattern = regexp.MustCompile(`\{day(?::(\d+))?\}`)
+)
+
+// loadMedia reads a media file. An empty path means no media.
+func loadMedia(path string) (*media, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &media{mimeType: "audio/mpeg", episodes: make(map[int]*episode)}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "url ") {
+			m.template = strings.TrimSpace(strings.TrimPrefix(line, "url "))
+			continue
+		}
+		if strings.HasPrefix(line, "type ") {
+			m.mimeType = strings.TrimSpace(strings.TrimPrefix(line, "type "))
+			continue
+		}
+
+		fields := strings.Split(line, "|")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		match := mediaDayPattern.FindStringSubmatch(fields[0])
+		if match == nil {
+			return nil, fmt.Errorf("%s:%d: expected \"Day N | title | duration | url\", got %q", path, n, line)
+		}
+		number, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		ep := &episode{}
+		if len(fields) > 1 {
+			ep.title = fields[1]
+		}
+		if len(fields) > 2 && fields[2] != "" {
+			if ep.duration, err = time.ParseDuration(fields[2]); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid duration %q", path, n, fields[2])
+			}
+		}
+		if len(fields) > 3 {
+			ep.url = fields[3]
+		}
+		m.episodes[number] = ep
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// episode returns the episode for a day, or nil if it has none.
+func (m *media) episode(day int) *episode {
+	if m == nil {
+		return nil
+	}
+	ep, ok := m.episodes[day]
+	if !ok {
+		if m.template == "" {
+			return nil
+		}
+		ep = &episode{}
+	}
+	if ep.url == "" && m.template != "" {
+		return &episode{title: ep.title, duration: ep.duration, url: expandDay(m.template, day)}
+	}
+	if ep.url == "" {
+		return nil
+	}
+	return ep
+}
+
+// expandDay fills in the {day} placeholders of a url template.
+func expandDay(template string, day int) string {
+	return templateDayPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		width := templateDayPattern.FindStringSubmatch(placeholder)[1]
+		if width == "" {
+			return strconv.Itoa(day)
+		}
+		return fmt.Sprintf("%0"+width+"d", day)
+	})
+}
+
+// link returns the text of the episode's link in the description.
+func (ep *episode) link(l *locale) string {
+	text := ep.title
+	if text == "" {
+		text = l.message("listen")
+	}
+	if ep.duration > 0 {
+		text += fmt.Sprintf(" (%d min)", int(ep.duration.Round(time.Minute)/time.Minute))
+	}
+	return text
+}
+
+// properties returns the URL and ATTACH properties for the episode.
+func (m *media) properties(ep *episode) string {
+	return fmt.Sprintf("\nURL:%s\nATTACH;FMTTYPE=%s:%s", ep.url, m.mimeType, ep.url)
+}
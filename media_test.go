@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMediaEvent checks that an episode's URL reads back whole from the
+// folded ATTACH and URL properties, and that its title is escaped in every
+// description style.
+func TestMediaEvent(t *testing.T) {
+	const (
+		url   = "https://example.com/podcasts/bible-in-a-year/season-one/episodes/day-001.mp3"
+		title = "Creation, and the Fall; a very long episode title indeed"
+	)
+	for name, describe := range descriptionStyles {
+		t.Run(name, func(t *testing.T) {
+			g := newTestGenerator(t)
+			g.describe = describe
+			g.episodes = &media{
+				template: "https://example.com/podcasts/bible-in-a-year/season-one/episodes/day-{day:3}.mp3",
+				mimeType: "audio/mpeg",
+				episodes: map[int]*episode{1: {title: title, duration: 21 * time.Minute}},
+			}
+			var buf bytes.Buffer
+			if err := g.writeCalendar(&buf, planFile("testdata/notes.txt"), nil); err != nil {
+				t.Fatal(err)
+			}
+			cals, err := parseICS(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev := cals[0].children("VEVENT")[0]
+			for _, prop := range []string{"ATTACH", "URL"} {
+				if got := ev.value(prop); got != url {
+					t.Errorf("%s = %q, want %q", prop, got, url)
+				}
+			}
+			description := ev.value("DESCRIPTION")
+			if strings.Contains(strings.ReplaceAll(description, `\,`, ""), ",") || strings.Contains(strings.ReplaceAll(description, `\;`, ""), ";") {
+				t.Errorf("DESCRIPTION %q has unescaped commas or semicolons", description)
+			}
+			if unescaped := unescapeText(description); !strings.Contains(unescaped, "Creation, and the Fall;") {
+				t.Errorf("DESCRIPTION %q doesn't contain the episode title", unescaped)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notification is one day's readings, ready to be posted to a chat.
+type notification struct {
+	// calendar is the name of the calendar the readings are from
+	calendar string
+	date     time.Time
+	day      *day
+	title    string
+	parts    *descriptionParts
+	locale   *locale
+}
+
+// webhook is a chat or service to post notifications to.
+type webhook struct {
+	kind string
+	url  string
+}
+
+// webhookFormats build the JSON payload each kind of webhook expects.
+var webhookFormats = map[string]func(n *notification) interface{}{
+	"slack":   slackPayload,
+	"discord": discordPayload,
+	"matrix":  matrixPayload,
+	"json":    jsonPayload,
+}
+
+// webhookList collects repeated -webhook kind=url flags.
+type webhookList []webhook
+
+func (w *webhookList) String() string {
+	var s []string
+	for _, hook := range *w {
+		s = append(s, hook.kind+"="+hook.url)
+	}
+	return strings.Join(s, ",")
+}
+
+func (w *webhookList) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[1] == "" {
+		return fmt.Errorf("expected kind=url, got %q", value)
+	}
+	if _, ok := webhookFormats[kv[0]]; !ok {
+		kinds := make([]string, 0, len(webhookFormats))
+		for k := range webhookFormats {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		return fmt.Errorf("unknown webhook kind %q: expected one of %s", kv[0], strings.Join(kinds, ", "))
+	}
+	*w = append(*w, webhook{kind: kv[0], url: kv[1]})
+	return nil
+}
+
+func runNotify(args []string) error {
+	fs := flag.NewFlagSet("notify", flag.ContinueOnError)
+	var hooks webhookList
+	fs.Var(&hooks, "webhook", "kind=url to post to, where kind is slack, discord, matrix or json (repeatable)")
+	var (
+		start  = fs.String("start", defaultStartDate.Format("2006-01-02"), "date of day 1 of the plan")
+		date   = fs.String("date", time.Now().Format("2006-01-02"), "date to post readings for")
+		recur  = fs.String("recurrence", "yearly", "how the calendar's events repeat: yearly or none")
+		lang   = fs.String("lang", "en", "language of the message")
+		media  = fs.String("media", "", "file mapping days to companion podcast episodes")
+		dryRun = fs.Bool("dry-run", false, "print payloads instead of posting them")
+		conf   = fs.String("config", "", "TOML file of settings, as for generating calendars; flags given on the command line override it")
+	)
+	reading := addReadingFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bibleinayear notify [flags] plan.txt")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	planpath, err := applyConfigFile(fs, *conf)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 1 {
+		planpath = fs.Arg(0)
+	}
+	if fs.NArg() > 1 || planpath == "" {
+		fs.Usage()
+		return errors.New("Please provide path to plan file")
+	}
+	if len(hooks) == 0 {
+		return errors.New("Please provide at least one -webhook")
+	}
+
+	l, err := lookupLocale(*lang)
+	if err != nil {
+		return err
+	}
+	opts, err := reading.options(l)
+	if err != nil {
+		return err
+	}
+	episodes, err := loadMedia(*media)
+	if err != nil {
+		return err
+	}
+	startDate, err := time.Parse("2006-01-02", *start)
+	if err != nil {
+		return fmt.Errorf("Invalid start date %q: %v", *start, err)
+	}
+	sched, err := readingSchedule(startDate, *recur)
+	if err != nil {
+		return err
+	}
+	when, err := time.Parse("2006-01-02", *date)
+	if err != nil {
+		return fmt.Errorf("Invalid date %q: %v", *date, err)
+	}
+
+	planfile, err := os.Open(planpath)
+	if err != nil {
+		return err
+	}
+	days, err := parsePlan(planfile)
+	planfile.Close()
+	if err != nil {
+		return err
+	}
+
+	digests := buildDigests(l, opts.name, sched, days, when, when, 1)
+	if len(digests) == 0 {
+		return fmt.Errorf("No readings on %s", *date)
+	}
+	dg := digests[0]
+	n := &notification{
+		calendar: opts.name,
+		date:     when,
+		day:      dg.days[0],
+		title:    dayTitle(l, dg.days[0]),
+		parts:    newDescriptionParts(l, opts.refs, opts.links, opts.translations, dg.days[0], dg.starts[0], episodes.episode(dg.days[0].number)),
+		locale:   l,
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	for _, hook := range hooks {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(webhookFormats[hook.kind](n)); err != nil {
+			return err
+		}
+		body := buf.Bytes()
+		if *dryRun {
+			fmt.Printf("%s %s\n%s", hook.kind, hook.url, body)
+			continue
+		}
+		if err := post(client, hook, body); err != nil {
+			return fmt.Errorf("Posting to %s webhook: %v", hook.kind, err)
+		}
+	}
+	return nil
+}
+
+// post sends a payload to a webhook. Matrix client-server API URLs take a PUT
+// with a transaction id and an access token from MATRIX_TOKEN; everything else
+// takes a plain POST.
+func post(client *http.Client, hook webhook, body []byte) error {
+	method, url := http.MethodPost, hook.url
+	if hook.kind == "matrix" && strings.Contains(url, "/_matrix/client/") {
+		method = http.MethodPut
+		url = strings.TrimSuffix(url, "/") + "/" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv("MATRIX_TOKEN"); hook.kind == "matrix" && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// readingLines returns the day's readings one per line, each escaped and
+// between marks such as "*", since chat markup doesn't span line breaks.
+// escape may be nil.
+func (n *notification) readingLines(mark string, escape func(string) string) string {
+	lines := make([]string, len(n.parts.references))
+	for i, ref := range n.parts.references {
+		if escape != nil {
+			ref = escape(ref)
+		}
+		lines[i] = mark + ref + mark
+	}
+	return strings.Join(lines, "\n")
+}
+
+func slackPayload(n *notification) interface{} {
+	type text struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	type block struct {
+		Type     string `json:"type"`
+		Text     *text  `json:"text,omitempty"`
+		Elements []text `json:"elements,omitempty"`
+	}
+
+	blocks := []block{
+		{Type: "header", Text: &text{Type: "plain_text", Text: n.title}},
+	}
+	if n.parts.intro != "" {
+		blocks = append(blocks, block{Type: "section", Text: &text{Type: "mrkdwn", Text: slackEscape(n.parts.intro)}})
+	}
+	blocks = append(blocks, block{Type: "section", Text: &text{Type: "mrkdwn", Text: n.readingLines("*", slackEscape)}})
+	for _, note := range n.parts.notes {
+		blocks = append(blocks, block{Type: "section", Text: &text{Type: "mrkdwn", Text: "_" + slackEscape(note) + "_"}})
+	}
+	if len(n.parts.links) > 0 {
+		var links []string
+		for _, link := range n.parts.links {
+			links = append(links, fmt.Sprintf("<%s|%s>", link.url, slackEscape(link.text)))
+		}
+		blocks = append(blocks, block{Type: "context", Elements: []text{{Type: "mrkdwn", Text: strings.Join(links, " · ")}}})
+	}
+	return struct {
+		Text   string  `json:"text"`
+		Blocks []block `json:"blocks"`
+	}{n.title + ": " + strings.Join(n.parts.references, "; "), blocks}
+}
+
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+func discordPayload(n *notification) interface{} {
+	type field struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	type footer struct {
+		Text string `json:"text"`
+	}
+	type embed struct {
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		URL         string  `json:"url,omitempty"`
+		Timestamp   string  `json:"timestamp"`
+		Fields      []field `json:"fields,omitempty"`
+		Footer      footer  `json:"footer"`
+	}
+
+	description := []string{n.readingLines("**", nil)}
+	if n.parts.intro != "" {
+		description = append([]string{n.parts.intro}, description...)
+	}
+	for _, note := range n.parts.notes {
+		description = append(description, "*"+note+"*")
+	}
+	e := embed{
+		Title:       n.title,
+		Description: strings.Join(description, "\n\n"),
+		Timestamp:   n.date.Format(time.RFC3339),
+		Footer:      footer{n.calendar},
+	}
+	if len(n.parts.links) > 0 {
+		e.URL = n.parts.links[0].url
+		var links []string
+		for _, link := range n.parts.links {
+			links = append(links, fmt.Sprintf("[%s](%s)", link.text, link.url))
+		}
+		e.Fields = []field{{Name: n.locale.message("read"), Value: strings.Join(links, " · ")}}
+	}
+	return struct {
+		Embeds []embed `json:"embeds"`
+	}{[]embed{e}}
+}
+
+func matrixPayload(n *notification) interface{} {
+	return struct {
+		MsgType       string `json:"msgtype"`
+		Body          string `json:"body"`
+		Format        string `json:"format"`
+		FormattedBody string `json:"formatted_body"`
+	}{
+		MsgType:       "m.text",
+		Body:          n.title + "\n\n" + plainText(n.locale, n.parts),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: "<h3>" + html.EscapeString(n.title) + "</h3>" + htmlText(n.locale, n.parts),
+	}
+}
+
+func jsonPayload(n *notification) interface{} {
+	type jsonReading struct {
+		Book     string   `json:"book"`
+		Passages []string `json:"passages"`
+		OSIS     []string `json:"osis,omitempty"`
+		USFM     string   `json:"usfm,omitempty"`
+		Track    string   `json:"track,omitempty"`
+	}
+	type jsonLink struct {
+		Text string `json:"text"`
+		URL  string `json:"url"`
+	}
+	payload := struct {
+		Calendar string        `json:"calendar"`
+		Date     string        `json:"date"`
+		Day      int           `json:"day"`
+		Period   string        `json:"period"`
+		Title    string        `json:"title"`
+		Intro    string        `json:"intro,omitempty"`
+		Readings []jsonReading `json:"readings"`
+		Notes    []string      `json:"notes,omitempty"`
+		Links    []jsonLink    `json:"links"`
+	}{
+		Calendar: n.calendar,
+		Date:     n.date.Format("2006-01-02"),
+		Day:      n.day.number,
+		Period:   n.day.period.name(n.locale),
+		Title:    n.title,
+		Intro:    n.parts.intro,
+		Notes:    n.parts.notes,
+	}
+	for _, r := range n.parts.readings {
+		payload.Readings = append(payload.Readings, jsonReading{
+			Book:     n.locale.book(r.book),
+			Passages: r.passages,
+			OSIS:     osisIDs(r),
+			USFM:     usfmCode(r),
+			Track:    trackName(n.locale, r.track),
+		})
+	}
+	for _, link := range n.parts.links {
+		payload.Links = append(payload.Links, jsonLink{Text: link.text, URL: link.url})
+	}
+	return payload
+}
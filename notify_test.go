@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testNotification returns the notification for day 5 of
+// testdata/notes.txt, which has several readings.
+func testNotification(t *testing.T) *notification {
+	t.Helper()
+	f, err := os.Open("testdata/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	days, err := parsePlan(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := locales["en"]
+	d := days[4]
+	return &notification{
+		calendar: "Group Reading",
+		date:     time.Date(2021, 1, 5, 0, 0, 0, 0, time.UTC),
+		day:      d,
+		title:    dayTitle(l, d),
+		parts:    newDescriptionParts(l, defaultReferences, bibleGatewayLinks, []string{"RSVCE"}, d, false, nil),
+		locale:   l,
+	}
+}
+
+// payload returns the JSON a webhook kind would be sent for n, decoded
+// generically.
+func payload(t *testing.T, kind string, n *notification) map[string]interface{} {
+	t.Helper()
+	b, err := json.Marshal(webhookFormats[kind](n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// field returns the value at path in v, where strings index objects and ints
+// index arrays, or nil.
+func field(v interface{}, path ...interface{}) interface{} {
+	for _, key := range path {
+		switch k := key.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			v = m[k]
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || k >= len(a) {
+				return nil
+			}
+			v = a[k]
+		}
+	}
+	return v
+}
+
+func TestNotificationPayloads(t *testing.T) {
+	n := testNotification(t)
+	const link = "https://www.biblegateway.com/passage/?search=1%20Samuel+1-2%3BSong%20of%20Songs+2%3BActs+1:1-11%3BPsalm+3&version=RSVCE"
+	tests := []struct {
+		kind string
+		path []interface{}
+		want interface{}
+	}{
+		{"slack", []interface{}{"text"}, "Day 5: Royal Kingdom: 1 Samuel 1-2; Song of Songs 2; Acts 1:1-11; Psalm 3"},
+		{"slack", []interface{}{"blocks", 0, "text", "text"}, "Day 5: Royal Kingdom"},
+		{"slack", []interface{}{"blocks", 1, "text", "text"}, "*1 Samuel 1-2*\n*Song of Songs 2*\n*Acts 1:1-11*\n*Psalm 3*"},
+		{"slack", []interface{}{"blocks", 2, "elements", 0, "text"}, "<" + link + "|RSVCE>"},
+		{"discord", []interface{}{"embeds", 0, "title"}, "Day 5: Royal Kingdom"},
+		{"discord", []interface{}{"embeds", 0, "url"}, link},
+		{"discord", []interface{}{"embeds", 0, "timestamp"}, "2021-01-05T00:00:00Z"},
+		{"discord", []interface{}{"embeds", 0, "footer", "text"}, "Group Reading"},
+		{"matrix", []interface{}{"msgtype"}, "m.text"},
+		{"matrix", []interface{}{"format"}, "org.matrix.custom.html"},
+		{"json", []interface{}{"calendar"}, "Group Reading"},
+		{"json", []interface{}{"date"}, "2021-01-05"},
+		{"json", []interface{}{"day"}, 5.0},
+		{"json", []interface{}{"period"}, "Royal Kingdom"},
+		{"json", []interface{}{"readings", 2, "book"}, "Acts"},
+		{"json", []interface{}{"readings", 2, "passages"}, []interface{}{"1:1-11"}},
+		{"json", []interface{}{"readings", 2, "osis"}, []interface{}{"Acts.1.1-Acts.1.11"}},
+		{"json", []interface{}{"readings", 2, "usfm"}, "ACT"},
+		{"json", []interface{}{"readings", 3, "track"}, "Psalm"},
+		{"json", []interface{}{"links", 0, "url"}, link},
+	}
+	for _, tt := range tests {
+		if got := field(payload(t, tt.kind, n), tt.path...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %v = %#v, want %#v", tt.kind, tt.path, got, tt.want)
+		}
+	}
+
+	description, _ := field(payload(t, "discord", n), "embeds", 0, "description").(string)
+	if want := "**1 Samuel 1-2**\n**Song of Songs 2**\n**Acts 1:1-11**\n**Psalm 3**"; !strings.Contains(description, want) {
+		t.Errorf("discord description %q doesn't bold each reading", description)
+	}
+	for _, key := range []string{"body", "formatted_body"} {
+		if s, _ := field(payload(t, "matrix", n), key).(string); !strings.Contains(s, "Acts 1:1-11") {
+			t.Errorf("matrix %s %q doesn't list the readings", key, s)
+		}
+	}
+}
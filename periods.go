@@ -0,0 +1,296 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// period is a narrative period of the plan, such as "Egypt and Exodus". Its
+// id stays the same whatever language the plan or calendar is written in.
+type period struct {
+	id           string
+	names        map[string]string
+	descriptions map[string]string
+}
+
+// knownPeriods are the periods of the Bible in a Year plan, with their names
+// and descriptions in every supported language.
+var knownPeriods = []*period{
+	{id: "early-world", names: map[string]string{
+		"en": "Early World",
+		"es": "Mundo primitivo",
+		"pt": "Mundo primitivo",
+		"pl": "Wczesny świat",
+		"fr": "Monde primitif",
+		"tl": "Sinaunang Daigdig",
+	}, descriptions: map[string]string{
+		"en": "God creates the world, and sin and its consequences spread through humanity.",
+		"es": "Dios crea el mundo, y el pecado y sus consecuencias se extienden por la humanidad.",
+		"pt": "Deus cria o mundo, e o pecado e suas consequências se espalham pela humanidade.",
+		"pl": "Bóg stwarza świat, a grzech i jego skutki rozprzestrzeniają się wśród ludzi.",
+		"fr": "Dieu crée le monde, et le péché et ses conséquences se répandent dans l'humanité.",
+		"tl": "Nilikha ng Diyos ang daigdig, at lumaganap sa sangkatauhan ang kasalanan at ang mga bunga nito.",
+	}},
+	{id: "patriarchs", names: map[string]string{
+		"en": "Patriarchs",
+		"es": "Patriarcas",
+		"pt": "Patriarcas",
+		"pl": "Patriarchowie",
+		"fr": "Patriarches",
+		"tl": "Mga Patriarka",
+	}, descriptions: map[string]string{
+		"en": "God calls Abraham and makes a covenant with him and his descendants Isaac and Jacob.",
+		"es": "Dios llama a Abraham y hace una alianza con él y con sus descendientes Isaac y Jacob.",
+		"pt": "Deus chama Abraão e faz uma aliança com ele e com seus descendentes Isaac e Jacó.",
+		"pl": "Bóg powołuje Abrahama i zawiera przymierze z nim i jego potomkami, Izaakiem i Jakubem.",
+		"fr": "Dieu appelle Abraham et fait alliance avec lui et ses descendants Isaac et Jacob.",
+		"tl": "Tinawag ng Diyos si Abraham at nakipagtipan sa kanya at sa kanyang mga inapo na sina Isaac at Jacob.",
+	}},
+	{id: "egypt-and-exodus", names: map[string]string{
+		"en": "Egypt and Exodus",
+		"es": "Egipto y Éxodo",
+		"pt": "Egito e Êxodo",
+		"pl": "Egipt i Wyjście",
+		"fr": "Égypte et Exode",
+		"tl": "Ehipto at Exodo",
+	}, descriptions: map[string]string{
+		"en": "God frees Israel from slavery in Egypt and gives the Law at Mount Sinai.",
+		"es": "Dios libera a Israel de la esclavitud en Egipto y le da la Ley en el monte Sinaí.",
+		"pt": "Deus liberta Israel da escravidão no Egito e dá a Lei no monte Sinai.",
+		"pl": "Bóg wyzwala Izraela z niewoli egipskiej i daje mu Prawo na górze Synaj.",
+		"fr": "Dieu libère Israël de l'esclavage en Égypte et lui donne la Loi au mont Sinaï.",
+		"tl": "Pinalaya ng Diyos ang Israel mula sa pagkaalipin sa Ehipto at ibinigay ang Kautusan sa Bundok ng Sinai.",
+	}},
+	{id: "desert-wanderings", names: map[string]string{
+		"en": "Desert Wanderings",
+		"es": "Peregrinación por el desierto",
+		"pt": "Peregrinação no deserto",
+		"pl": "Wędrówka po pustyni",
+		"fr": "Errance dans le désert",
+		"tl": "Paglalakbay sa Ilang",
+	}, descriptions: map[string]string{
+		"en": "Israel wanders forty years in the wilderness before entering the Promised Land.",
+		"es": "Israel vaga cuarenta años por el desierto antes de entrar en la Tierra Prometida.",
+		"pt": "Israel vagueia quarenta anos pelo deserto antes de entrar na Terra Prometida.",
+		"pl": "Izrael przez czterdzieści lat wędruje po pustyni, zanim wejdzie do Ziemi Obiecanej.",
+		"fr": "Israël erre quarante ans dans le désert avant d'entrer en Terre promise.",
+		"tl": "Naglakbay ang Israel nang apatnapung taon sa ilang bago pumasok sa Lupang Pangako.",
+	}},
+	{id: "conquest-and-judges", names: map[string]string{
+		"en": "Conquest and Judges",
+		"es": "Conquista y Jueces",
+		"pt": "Conquista e Juízes",
+		"pl": "Podbój i Sędziowie",
+		"fr": "Conquête et Juges",
+		"tl": "Pananakop at mga Hukom",
+	}, descriptions: map[string]string{
+		"en": "Israel enters the Promised Land, and judges deliver the people whenever they turn away from God.",
+		"es": "Israel entra en la Tierra Prometida, y los jueces liberan al pueblo cada vez que se aparta de Dios.",
+		"pt": "Israel entra na Terra Prometida, e os juízes libertam o povo sempre que ele se afasta de Deus.",
+		"pl": "Izrael wchodzi do Ziemi Obiecanej, a sędziowie ratują lud, gdy tylko odstępuje od Boga.",
+		"fr": "Israël entre en Terre promise, et les juges délivrent le peuple chaque fois qu'il se détourne de Dieu.",
+		"tl": "Pumasok ang Israel sa Lupang Pangako, at iniligtas ng mga hukom ang bayan tuwing tumatalikod ito sa Diyos.",
+	}},
+	{id: "messianic-checkpoint", names: map[string]string{
+		"en": "Messianic Checkpoint",
+		"es": "Punto de control mesiánico",
+		"pt": "Ponto de controle messiânico",
+		"pl": "Mesjański punkt kontrolny",
+		"fr": "Étape messianique",
+		"tl": "Pagsusuri sa Mesiyas",
+	}, descriptions: map[string]string{
+		"en": "A pause in the Gospels to see how the story so far points to Christ.",
+		"es": "Una pausa en los Evangelios para ver cómo la historia hasta aquí apunta a Cristo.",
+		"pt": "Uma pausa nos Evangelhos para ver como a história até aqui aponta para Cristo.",
+		"pl": "Przerwa na Ewangelie, by zobaczyć, jak dotychczasowa historia wskazuje na Chrystusa.",
+		"fr": "Une pause dans les Évangiles pour voir comment l'histoire jusqu'ici annonce le Christ.",
+		"tl": "Isang paghinto sa mga Ebanghelyo upang makita kung paano itinuturo kay Kristo ang kuwento sa ngayon.",
+	}},
+	{id: "royal-kingdom", names: map[string]string{
+		"en": "Royal Kingdom",
+		"es": "Reino real",
+		"pt": "Reino real",
+		"pl": "Królestwo",
+		"fr": "Royaume",
+		"tl": "Maharlikang Kaharian",
+	}, descriptions: map[string]string{
+		"en": "Israel becomes a kingdom under Saul, David and Solomon.",
+		"es": "Israel se convierte en un reino bajo Saúl, David y Salomón.",
+		"pt": "Israel se torna um reino sob Saul, Davi e Salomão.",
+		"pl": "Izrael staje się królestwem pod rządami Saula, Dawida i Salomona.",
+		"fr": "Israël devient un royaume sous Saül, David et Salomon.",
+		"tl": "Naging kaharian ang Israel sa ilalim nina Saul, David at Solomon.",
+	}},
+	{id: "divided-kingdom", names: map[string]string{
+		"en": "Divided Kingdom",
+		"es": "Reino dividido",
+		"pt": "Reino dividido",
+		"pl": "Królestwo podzielone",
+		"fr": "Royaume divisé",
+		"tl": "Nahating Kaharian",
+	}, descriptions: map[string]string{
+		"en": "The kingdom splits in two, and the prophets call Israel and Judah back to God.",
+		"es": "El reino se divide en dos, y los profetas llaman a Israel y a Judá a volver a Dios.",
+		"pt": "O reino se divide em dois, e os profetas chamam Israel e Judá de volta para Deus.",
+		"pl": "Królestwo dzieli się na dwa, a prorocy wzywają Izraela i Judę do powrotu do Boga.",
+		"fr": "Le royaume se divise en deux, et les prophètes appellent Israël et Juda à revenir à Dieu.",
+		"tl": "Nahati sa dalawa ang kaharian, at tinawag ng mga propeta ang Israel at Juda na magbalik sa Diyos.",
+	}},
+	{id: "exile", names: map[string]string{
+		"en": "Exile",
+		"es": "Exilio",
+		"pt": "Exílio",
+		"pl": "Wygnanie",
+		"fr": "Exil",
+		"tl": "Pagkatapon",
+	}, descriptions: map[string]string{
+		"en": "Israel and Judah are conquered and carried into exile in Assyria and Babylon.",
+		"es": "Israel y Judá son conquistados y llevados al exilio en Asiria y Babilonia.",
+		"pt": "Israel e Judá são conquistados e levados para o exílio na Assíria e na Babilônia.",
+		"pl": "Izrael i Juda zostają podbici i uprowadzeni na wygnanie do Asyrii i Babilonu.",
+		"fr": "Israël et Juda sont conquis et emmenés en exil en Assyrie et à Babylone.",
+		"tl": "Nasakop ang Israel at Juda at dinalang bihag sa Asiria at Babilonia.",
+	}},
+	{id: "return", names: map[string]string{
+		"en": "Return",
+		"es": "Regreso",
+		"pt": "Retorno",
+		"pl": "Powrót",
+		"fr": "Retour",
+		"tl": "Pagbabalik",
+	}, descriptions: map[string]string{
+		"en": "The exiles return to Jerusalem and rebuild the Temple and the city walls.",
+		"es": "Los exiliados regresan a Jerusalén y reconstruyen el Templo y las murallas de la ciudad.",
+		"pt": "Os exilados voltam a Jerusalém e reconstroem o Templo e as muralhas da cidade.",
+		"pl": "Wygnańcy wracają do Jerozolimy i odbudowują Świątynię oraz mury miasta.",
+		"fr": "Les exilés reviennent à Jérusalem et rebâtissent le Temple et les murailles de la ville.",
+		"tl": "Bumalik sa Jerusalem ang mga tinapon at muling itinayo ang Templo at ang mga pader ng lungsod.",
+	}},
+	{id: "maccabean-revolt", names: map[string]string{
+		"en": "Maccabean Revolt",
+		"es": "Rebelión macabea",
+		"pt": "Revolta dos Macabeus",
+		"pl": "Powstanie Machabeuszy",
+		"fr": "Révolte des Maccabées",
+		"tl": "Paghihimagsik ng mga Macabeo",
+	}, descriptions: map[string]string{
+		"en": "The Maccabees lead the Jews in revolt against Greek rule to defend their faith.",
+		"es": "Los macabeos encabezan la rebelión de los judíos contra el dominio griego para defender su fe.",
+		"pt": "Os Macabeus lideram a revolta dos judeus contra o domínio grego para defender sua fé.",
+		"pl": "Machabeusze prowadzą powstanie Żydów przeciw panowaniu greckiemu w obronie wiary.",
+		"fr": "Les Maccabées mènent la révolte des Juifs contre la domination grecque pour défendre leur foi.",
+		"tl": "Pinamunuan ng mga Macabeo ang paghihimagsik ng mga Judio laban sa mga Griyego upang ipagtanggol ang kanilang pananampalataya.",
+	}},
+	{id: "messianic-fulfillment", names: map[string]string{
+		"en": "Messianic Fulfillment",
+		"es": "Cumplimiento mesiánico",
+		"pt": "Cumprimento messiânico",
+		"pl": "Mesjańskie wypełnienie",
+		"fr": "Accomplissement messianique",
+		"tl": "Katuparan ng Mesiyas",
+	}, descriptions: map[string]string{
+		"en": "Jesus fulfills God's promises in his life, death and resurrection.",
+		"es": "Jesús cumple las promesas de Dios en su vida, muerte y resurrección.",
+		"pt": "Jesus cumpre as promessas de Deus em sua vida, morte e ressurreição.",
+		"pl": "Jezus wypełnia Boże obietnice w swoim życiu, śmierci i zmartwychwstaniu.",
+		"fr": "Jésus accomplit les promesses de Dieu par sa vie, sa mort et sa résurrection.",
+		"tl": "Tinupad ni Hesus ang mga pangako ng Diyos sa kanyang buhay, kamatayan at muling pagkabuhay.",
+	}},
+	{id: "the-church", names: map[string]string{
+		"en": "The Church",
+		"es": "La Iglesia",
+		"pt": "A Igreja",
+		"pl": "Kościół",
+		"fr": "L'Église",
+		"tl": "Ang Simbahan",
+	}, descriptions: map[string]string{
+		"en": "The Holy Spirit comes upon the Apostles, and the Church carries the Gospel to the world.",
+		"es": "El Espíritu Santo desciende sobre los apóstoles, y la Iglesia lleva el Evangelio al mundo.",
+		"pt": "O Espírito Santo desce sobre os apóstolos, e a Igreja leva o Evangelho ao mundo.",
+		"pl": "Duch Święty zstępuje na apostołów, a Kościół niesie Ewangelię światu.",
+		"fr": "L'Esprit Saint descend sur les Apôtres, et l'Église porte l'Évangile au monde.",
+		"tl": "Bumaba ang Espiritu Santo sa mga Apostol, at ipinalaganap ng Simbahan ang Ebanghelyo sa buong mundo.",
+	}},
+}
+
+// periodsByName finds known periods by id or by name in any language,
+// ignoring case.
+var periodsByName = func() map[string]*period {
+	m := make(map[string]*period)
+	for _, p := range knownPeriods {
+		m[p.id] = p
+		for _, name := range p.names {
+			m[strings.ToLower(name)] = p
+		}
+	}
+	return m
+}()
+
+var nonSlugPattern = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// lookupPeriod returns the period a plan header or summary refers to. Unknown
+// names become a new period shown as written in every language, with an id
+// derived from the name. An empty name means no period.
+func lookupPeriod(name string) *period {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	if p, ok := periodsByName[strings.ToLower(name)]; ok {
+		return p
+	}
+	return &period{id: slugify(name), names: map[string]string{"en": name}}
+}
+
+// slugify turns a name into a lower-case id made of letters, digits and
+// dashes.
+func slugify(name string) string {
+	name = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(name))
+	return strings.Trim(nonSlugPattern.ReplaceAllString(name, "-"), "-")
+}
+
+// name returns the display name of the period in l's language, falling back
+// to English.
+func (p *period) name(l *locale) string {
+	if p == nil {
+		return ""
+	}
+	if name, ok := p.names[l.tag]; ok {
+		return name
+	}
+	if name, ok := p.names["en"]; ok {
+		return name
+	}
+	return p.id
+}
+
+// description returns the period's introduction in l's language, falling
+// back to English, or "" if it has none.
+func (p *period) description(l *locale) string {
+	if p == nil {
+		return ""
+	}
+	if description, ok := p.descriptions[l.tag]; ok {
+		return description
+	}
+	return p.descriptions["en"]
+}
+
+// describedAs reports whether s is the period's description in any
+// language.
+func (p *period) describedAs(s string) bool {
+	for _, description := range p.descriptions {
+		if description == s {
+			return true
+		}
+	}
+	return false
+}
+
+// samePeriod reports whether a and b are the same period.
+func samePeriod(a, b *period) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.id == b.id
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// day is one day of a reading plan.
+type day struct {
+	number   int
+	period   *period
+	readings []*reading
+	// intro introduces the period that starts on this day, if any.
+	intro string
+	// notes is commentary or reflection questions for the day.
+	notes []string
+}
+
+// notePrefix starts a note line in a plan. A note after a period header is
+// the period's introduction; a note after a day line belongs to that day.
+const notePrefix = ">"
+
+// parsePlan reads a whole plan in plan.txt format. Use a planReader to
+// process long plans without holding them in memory.
+func parsePlan(r io.Reader) ([]*day, error) {
+	var days []*day
+	p := newPlanReader(r)
+	for {
+		d, err := p.next()
+		if err == io.EOF {
+			return days, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+}
+
+// planReader reads a plan in plan.txt format one day at a time. Lines
+// starting with "Day", or the same word in a supported language, are
+// readings; lines starting with ">" are notes; any other line starts a new
+// narrative period. Book names may be English or localized, and readings may
+// be labelled with their track, as in "[Psalm] Psalm 19".
+type planReader struct {
+	scanner *bufio.Scanner
+	// period is the narrative period we're currently in
+	period *period
+	// intro collects notes between a period header and its first day
+	intro []string
+	// pending is the last day read, held back until all its notes are read
+	pending *day
+}
+
+func newPlanReader(r io.Reader) *planReader {
+	return &planReader{scanner: bufio.NewScanner(r)}
+}
+
+// next returns the next day of the plan, or io.EOF after the last one.
+func (p *planReader) next() (*day, error) {
+	for p.scanner.Scan() {
+		if note := p.scanner.Text(); strings.HasPrefix(note, notePrefix) {
+			note = strings.TrimSpace(strings.TrimPrefix(note, notePrefix))
+			if p.pending != nil {
+				p.pending.notes = append(p.pending.notes, note)
+			} else {
+				p.intro = append(p.intro, note)
+			}
+			continue
+		}
+
+		text := p.scanner.Text()
+
+		splits := strings.Fields(text)
+		if len(splits) == 0 {
+			splits = []string{""}
+		}
+		if _, ok := dayWords[splits[0]]; !ok {
+			p.period = lookupPeriod(text)
+			p.intro = nil
+			if d := p.pending; d != nil {
+				p.pending = nil
+				return d, nil
+			}
+			continue
+		}
+
+		if len(splits) < 3 {
+			return nil, fmt.Errorf("Invalid line: expected at least 3 splits. Got: %s", text)
+		}
+
+		number, err := strconv.Atoi(strings.TrimRight(splits[1], ",;"))
+		if err != nil {
+			return nil, err
+		}
+
+		d := p.pending
+		p.pending = &day{
+			number:   number,
+			period:   p.period,
+			readings: parseTrackedReadings(strings.Join(splits[2:], " ")),
+			intro:    strings.Join(p.intro, "\n"),
+		}
+		p.intro = nil
+		if d != nil {
+			return d, nil
+		}
+	}
+	if err := p.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if d := p.pending; d != nil {
+		p.pending = nil
+		return d, nil
+	}
+	return nil, io.EOF
+}
+
+// writePlan writes days in plan.txt format, starting a new period header
+// whenever the period changes.
+func writePlan(w io.Writer, days []*day) error {
+	en := locales["en"]
+	for i, d := range days {
+		if i == 0 || !samePeriod(d.period, days[i-1].period) || d.intro != "" {
+			if err := writeLine(w, d.period.name(en)); err != nil {
+				return err
+			}
+			if err := writeNotes(w, strings.Split(d.intro, "\n")); err != nil {
+				return err
+			}
+		}
+		line := fmt.Sprintf("Day %d", d.number)
+		labels := labelTracks(d.readings)
+		for i, r := range d.readings {
+			labelled := labels && (i == 0 || r.track != d.readings[i-1].track)
+			if labelled {
+				line += " [" + r.track + "]"
+			}
+			if (r.joined && !labelled) || readingBoundary(line, r.book) {
+				line += ","
+			}
+			line += " " + r.book
+			if len(r.passages) > 0 {
+				line += " " + strings.Join(r.passages, ", ")
+			}
+		}
+		if err := writeLine(w, line); err != nil {
+			return err
+		}
+		if err := writeNotes(w, d.notes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeNotes(w io.Writer, notes []string) error {
+	for _, note := range notes {
+		if note == "" {
+			continue
+		}
+		if err := writeLine(w, notePrefix+" "+note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readingBoundary returns whether a comma must end line before book is
+// added, because the last word of line would otherwise be read as part of the
+// book's name, as in "Genesis 1, John 1".
+func readingBoundary(line, book string) bool {
+	last := line[strings.LastIndex(line, " ")+1:]
+	_, n := matchBook(tokenizeReferences(last + " " + book))
+	return n > 1
+}
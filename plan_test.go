@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseShippedPlan(t *testing.T) {
+	f, err := os.Open("plan.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	days, err := parsePlan(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 365 {
+		t.Fatalf("got %d days, want 365", len(days))
+	}
+	for i, d := range days {
+		if d.number != i+1 {
+			t.Errorf("day %d is numbered %d", i+1, d.number)
+		}
+		if d.period == nil {
+			t.Errorf("day %d has no period", d.number)
+		}
+		if len(d.readings) == 0 {
+			t.Errorf("day %d has no readings", d.number)
+		}
+		for _, r := range d.readings {
+			if _, ok := books[r.book]; !ok {
+				t.Errorf("day %d: unknown book %q", d.number, r.book)
+			}
+		}
+	}
+	if got := readingsText(days[0].readings); got != "Genesis 1-2; Psalm 19" {
+		t.Errorf("day 1 readings are %q", got)
+	}
+	if got := days[0].period.id; got != "early-world" {
+		t.Errorf("day 1 period is %q", got)
+	}
+}
+
+func TestParsePlanNotes(t *testing.T) {
+	f, err := os.Open("testdata/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	days, err := parsePlan(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 5 {
+		t.Fatalf("got %d days, want 5", len(days))
+	}
+	if want := "In the beginning, God creates the world and calls it good."; days[0].intro != want {
+		t.Errorf("day 1 intro is %q, want %q", days[0].intro, want)
+	}
+	if want := []string{"Reflect: what does it mean that you are made in God's image?"}; !reflect.DeepEqual(days[0].notes, want) {
+		t.Errorf("day 1 notes are %q, want %q", days[0].notes, want)
+	}
+	if days[1].notes != nil || days[1].intro != "" {
+		t.Errorf("day 2 has notes %q and intro %q, want none", days[1].notes, days[1].intro)
+	}
+	if got := days[3].period.id; got != "patriarchs" {
+		t.Errorf("day 4 period is %q, want patriarchs", got)
+	}
+	if got, want := readingsText(days[3].readings), "Genesis 14; Psalm 3"; got != want {
+		t.Errorf("day 4 readings are %q, want %q", got, want)
+	}
+}
+
+// TestPlanRoundTrip checks that writing a plan and reading it back gives the
+// same days.
+func TestPlanRoundTrip(t *testing.T) {
+	for _, path := range []string{"plan.txt", "testdata/notes.txt"} {
+		t.Run(path, func(t *testing.T) {
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			days, err := parsePlan(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := writePlan(&buf, days); err != nil {
+				t.Fatal(err)
+			}
+			again, err := parsePlan(strings.NewReader(buf.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changes := diffDays(days, again); len(changes) > 0 {
+				t.Errorf("%d days changed, starting with day %d", len(changes), changes[0].number)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// planRules are the orders the plan command reads the Bible in, as streams
+// for parseStreams.
+var planRules = map[string]string{
+	// canonical reads the canon from Genesis to Revelation.
+	"canonical": "Genesis-Revelation",
+	// interleaved reads the Old Testament, the New Testament, the Psalms and
+	// the Proverbs side by side, like plan.txt.
+	"interleaved": "Old Testament: Genesis-Job, Ecclesiastes-Malachi; New Testament: Matthew-Revelation; Psalm: Psalms; Wisdom: Proverbs",
+	// chronological reads the books in roughly the order of the events they
+	// tell of, or of their writing.
+	"chronological": strings.Join(chronologicalOrder, ", "),
+}
+
+// chronologicalOrder is every book of the canon in roughly historical
+// order.
+var chronologicalOrder = []string{
+	"Genesis", "Job", "Exodus", "Leviticus", "Numbers", "Deuteronomy",
+	"Joshua", "Judges", "Ruth", "1 Samuel", "2 Samuel", "1 Chronicles",
+	"Psalms", "1 Kings", "Proverbs", "Ecclesiastes", "Song of Songs",
+	"2 Kings", "2 Chronicles", "Jonah", "Amos", "Hosea", "Isaiah", "Micah",
+	"Joel", "Obadiah", "Nahum", "Zephaniah", "Habakkuk", "Jeremiah",
+	"Lamentations", "Baruch", "Ezekiel", "Daniel", "Tobit", "Judith",
+	"Esther", "Ezra", "Haggai", "Zechariah", "Nehemiah", "Malachi", "Sirach",
+	"1 Maccabees", "2 Maccabees", "Wisdom",
+	"Matthew", "Mark", "Luke", "John", "Acts", "James", "Galatians",
+	"1 Thessalonians", "2 Thessalonians", "1 Corinthians", "2 Corinthians",
+	"Romans", "Philippians", "Colossians", "Philemon", "Ephesians",
+	"1 Timothy", "Titus", "1 Peter", "2 Timothy", "2 Peter", "Hebrews",
+	"Jude", "1 John", "2 John", "3 John", "Revelation",
+}
+
+func runPlan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	rule := fs.String("rule", "canonical", "Order to read in: canonical, interleaved, chronological or custom")
+	streams := fs.String("streams", "", `Books to read for the custom rule, e.g. "Genesis-Malachi; Gospels: Matthew-John; Psalms". Streams are separated by semicolons, read side by side, and may be named as tracks`)
+	days := fs.Int("days", 365, "Number of days in the plan")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bibleinayear plan [flags] [PLAN.txt]")
+		fmt.Fprintln(fs.Output(), "Writes the plan to standard output if PLAN.txt is omitted.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("Please provide at most one plan to write")
+	}
+
+	spec, ok := planRules[*rule]
+	switch {
+	case *rule == "custom":
+		if *streams == "" {
+			return errors.New("The custom rule needs -streams")
+		}
+		spec = *streams
+	case !ok:
+		return fmt.Errorf("Unknown rule %q: expected canonical, interleaved, chronological or custom", *rule)
+	case *streams != "":
+		return errors.New("-streams can only be given with -rule custom")
+	}
+	books, err := parseStreams(spec)
+	if err != nil {
+		return err
+	}
+	plan, err := buildPlan(books, *days)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return writePlan(os.Stdout, plan)
+	}
+	out, err := os.Create(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := writePlan(out, plan); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// planStream is a list of books read one after another, in a track of the
+// plan. Without a name, the tracks of its readings are inferred.
+type planStream struct {
+	track string
+	books []*canonBook
+}
+
+// parseStreams reads streams such as "Genesis-Malachi; Gospels:
+// Matthew-John; Psalms". Streams are separated by semicolons, and each lists
+// books or ranges of books in canonical order, separated by commas, after an
+// optional track name and colon.
+func parseStreams(s string) ([]planStream, error) {
+	var streams []planStream
+	for _, spec := range strings.Split(s, ";") {
+		var stream planStream
+		if i := strings.Index(spec, ":"); i >= 0 {
+			stream.track, spec = strings.TrimSpace(spec[:i]), spec[i+1:]
+		}
+		for _, item := range strings.Split(spec, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			books, err := bookRange(item)
+			if err != nil {
+				return nil, err
+			}
+			stream.books = append(stream.books, books...)
+		}
+		if len(stream.books) > 0 {
+			streams = append(streams, stream)
+		}
+	}
+	if len(streams) == 0 {
+		return nil, errors.New("No books to read")
+	}
+	return streams, nil
+}
+
+// bookRange returns the books from "Genesis-Deuteronomy", or the single book
+// of "Psalms".
+func bookRange(s string) ([]*canonBook, error) {
+	ends := strings.SplitN(s, "-", 2)
+	var indexes []int
+	for _, end := range ends {
+		i := canonIndex(strings.TrimSpace(end))
+		if i < 0 {
+			return nil, fmt.Errorf("Unknown book %q", strings.TrimSpace(end))
+		}
+		indexes = append(indexes, i)
+	}
+	first, last := indexes[0], indexes[len(indexes)-1]
+	if last < first {
+		return nil, fmt.Errorf("Books out of order in %q", s)
+	}
+	return canon[first : last+1], nil
+}
+
+// canonIndex returns where a book, named in any supported language, comes in
+// the canon, or -1.
+func canonIndex(name string) int {
+	english, ok := lookupBook(name)
+	if !ok {
+		return -1
+	}
+	b := lookupCanon(english)
+	for i := range canon {
+		if canon[i] == b {
+			return i
+		}
+	}
+	return -1
+}
+
+// chapterRef is a chapter of a book, the unit plans are divided into.
+type chapterRef struct {
+	book    *canonBook
+	chapter int
+}
+
+// buildPlan divides each stream evenly over n days, so that all of them
+// finish on the last day. The first stream sets the pace: it must have a
+// chapter for every day, while shorter streams are read whole as many times
+// as it takes to have one too. Each day is in a period named after the book
+// of its first stream.
+func buildPlan(streams []planStream, n int) ([]*day, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("Invalid number of days %d", n)
+	}
+	spread := make([][][]chapterRef, len(streams))
+	for i, stream := range streams {
+		books := stream.books
+		var chapters []chapterRef
+		for _, b := range books {
+			for c := 1; c <= b.chapters; c++ {
+				chapters = append(chapters, chapterRef{b, c})
+			}
+		}
+		if i == 0 && len(chapters) < n {
+			return nil, fmt.Errorf("Too many days: %s to %s has only %d chapters to read in %d days", books[0].name, books[len(books)-1].name, len(chapters), n)
+		}
+		for once := len(chapters); len(chapters) < n; {
+			chapters = append(chapters, chapters[:once]...)
+		}
+		spread[i] = make([][]chapterRef, n)
+		for d := 0; d < n; d++ {
+			spread[i][d] = chapters[d*len(chapters)/n : (d+1)*len(chapters)/n]
+		}
+	}
+
+	days := make([]*day, n)
+	for i := range days {
+		d := &day{number: i + 1, period: lookupPeriod(spread[0][i][0].book.name)}
+		for j, stream := range spread {
+			for _, r := range chapterReadings(stream[i]) {
+				r.track = streams[j].track
+				d.readings = append(d.readings, r)
+			}
+		}
+		inferTracks(d.readings)
+		days[i] = d
+	}
+	return days, nil
+}
+
+// chapterReadings joins consecutive chapters of each book into one reading,
+// such as "Genesis 1-3". Books of one chapter are read whole, and a single
+// psalm is a "Psalm".
+func chapterReadings(chapters []chapterRef) []*reading {
+	var readings []*reading
+	for i := 0; i < len(chapters); {
+		b, first := chapters[i].book, chapters[i].chapter
+		j := i + 1
+		for j < len(chapters) && chapters[j].book == b && chapters[j].chapter == chapters[j-1].chapter+1 {
+			j++
+		}
+		last := chapters[j-1].chapter
+		r := &reading{book: b.name}
+		switch {
+		case b.chapters == 1:
+		case first == last:
+			if b.name == "Psalms" {
+				r.book = "Psalm"
+			}
+			r.passages = []string{strconv.Itoa(first)}
+		default:
+			r.passages = []string{fmt.Sprintf("%d-%d", first, last)}
+		}
+		readings = append(readings, r)
+		i = j
+	}
+	return readings
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestBuildPlan checks that each rule reads every chapter in order within
+// each stream, starting a stream again only once it is finished, and that
+// the plans read back the same.
+func TestBuildPlan(t *testing.T) {
+	for _, rule := range []string{"canonical", "interleaved", "chronological"} {
+		for _, n := range []int{365, 730} {
+			t.Run(fmt.Sprintf("%s/%d", rule, n), func(t *testing.T) {
+				streams, err := parseStreams(planRules[rule])
+				if err != nil {
+					t.Fatal(err)
+				}
+				days, err := buildPlan(streams, n)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(days) != n {
+					t.Fatalf("got %d days, want %d", len(days), n)
+				}
+
+				read := make(map[string]int)
+				for _, d := range days {
+					for _, r := range d.readings {
+						b := lookupCanon(r.book)
+						first, last := 1, b.chapters
+						if len(r.passages) > 0 {
+							start, end, _ := b.parsePassage(r.passages[0], 0)
+							first, last = start.chapter, end.chapter
+						}
+						if first == 1 && read[b.name] == b.chapters {
+							read[b.name] = 0
+						}
+						if first != read[b.name]+1 {
+							t.Fatalf("day %d: %s starts at chapter %d after %d", d.number, r.book, first, read[b.name])
+						}
+						read[b.name] = last
+					}
+				}
+				for _, stream := range streams {
+					for _, b := range stream.books {
+						if read[b.name] != b.chapters {
+							t.Errorf("read %s to chapter %d of %d", b.name, read[b.name], b.chapters)
+						}
+					}
+				}
+
+				var buf bytes.Buffer
+				if err := writePlan(&buf, days); err != nil {
+					t.Fatal(err)
+				}
+				again, err := parsePlan(strings.NewReader(buf.String()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if changes := diffDays(days, again); len(changes) > 0 {
+					t.Errorf("%d days changed, starting with day %d", len(changes), changes[0].number)
+				}
+			})
+		}
+	}
+}
+
+// TestInterleavedPlanReadsEveryStreamDaily checks that the shorter streams
+// of the interleaved rule have a reading every day, not only the first.
+func TestInterleavedPlanReadsEveryStreamDaily(t *testing.T) {
+	streams, err := parseStreams(planRules["interleaved"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	days, err := buildPlan(streams, 365)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range days {
+		tracks := make(map[string]bool)
+		for _, r := range d.readings {
+			tracks[r.track] = true
+		}
+		for _, stream := range streams {
+			if !tracks[stream.track] {
+				t.Fatalf("day %d has no %s reading", d.number, stream.track)
+			}
+		}
+	}
+	var books []string
+	for _, r := range days[0].readings {
+		books = append(books, r.book)
+	}
+	if got, want := strings.Join(books, ", "), "Genesis, Matthew, Psalm, Proverbs"; got != want {
+		t.Errorf("day 1 reads %s, want %s", got, want)
+	}
+}
+
+func TestChronologicalOrderCoversCanon(t *testing.T) {
+	seen := make(map[*canonBook]bool)
+	for _, name := range chronologicalOrder {
+		b := lookupCanon(name)
+		if b == nil {
+			t.Fatalf("%s isn't in the canon", name)
+		}
+		if seen[b] {
+			t.Errorf("%s is listed twice", name)
+		}
+		seen[b] = true
+	}
+	if len(seen) != len(canon) {
+		t.Errorf("got %d books, want %d", len(seen), len(canon))
+	}
+}
+
+func TestParseStreams(t *testing.T) {
+	streams, err := parseStreams("Gospels: Matthew-John; Salmos, Proverbs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, stream := range streams {
+		names := []string{stream.track}
+		for _, b := range stream.books {
+			names = append(names, b.name)
+		}
+		got = append(got, strings.Join(names, ","))
+	}
+	want := []string{"Gospels,Matthew,Mark,Luke,John", ",Psalms,Proverbs"}
+	if strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"John-Matthew", "Hezekiah", " ; "} {
+		if _, err := parseStreams(bad); err == nil {
+			t.Errorf("parseStreams(%q) succeeded", bad)
+		}
+	}
+	if _, err := buildPlan(streams, 100); err == nil {
+		t.Error("buildPlan succeeded with more days than chapters")
+	}
+}
+
+func TestWritePlanSeparatesBooks(t *testing.T) {
+	days := []*day{{
+		number:   1,
+		period:   lookupPeriod("Genesis"),
+		readings: []*reading{{book: "Genesis", passages: []string{"1"}}, {book: "John", passages: []string{"1"}}},
+	}}
+	var buf bytes.Buffer
+	if err := writePlan(&buf, days); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Genesis\nDay 1 Genesis 1, John 1\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
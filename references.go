@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxBookWords is the most words in any book name, such as "Acts of the
+// Apostles" or "Awit ng mga Awit".
+var maxBookWords = func() int {
+	max := 1
+	count := func(name string) {
+		if n := len(strings.Fields(name)); n > max {
+			max = n
+		}
+	}
+	for name := range books {
+		count(name)
+	}
+	for name := range localizedBooks {
+		count(name)
+	}
+	return max
+}()
+
+// referenceToken is a word of a list of references. sep is whether a comma or
+// semicolon follows it.
+type referenceToken struct {
+	text string
+	sep  bool
+}
+
+// tokenizeReferences splits s into words, noting where commas and
+// semicolons separate them.
+func tokenizeReferences(s string) []referenceToken {
+	var tokens []referenceToken
+	var word strings.Builder
+	flush := func(sep bool) {
+		if word.Len() > 0 {
+			tokens = append(tokens, referenceToken{text: word.String()})
+			word.Reset()
+		}
+		if sep && len(tokens) > 0 {
+			tokens[len(tokens)-1].sep = true
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r == ',' || r == ';':
+			flush(true)
+		case unicode.IsSpace(r):
+			flush(false)
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush(false)
+	return tokens
+}
+
+// parseReferences reads a list of references such as "Genesis 1-2 Psalm
+// 19", "1 Samuel 3:1-10, 15; Song of Songs 2" or "2 John, 3 John". At each
+// word the longest book name, in English or a supported language, starts a
+// new reading; other words are passages of the reading before them. Book
+// names don't span a comma or semicolon. Passages before the first book are
+// dropped, and the rest are normalized.
+func parseReferences(s string) []*reading {
+	var readings []*reading
+	var r *reading
+	tokens := tokenizeReferences(s)
+	for i := 0; i < len(tokens); {
+		if book, n := matchBook(tokens[i:]); n > 0 {
+			r = &reading{book: book, joined: r != nil && len(r.passages) == 0 && tokens[i-1].sep}
+			readings = append(readings, r)
+			i += n
+			continue
+		}
+		if r != nil {
+			r.passages = append(r.passages, tokens[i].text)
+		}
+		i++
+	}
+	for _, r := range readings {
+		r.passages = normalizePassages(r.passages)
+	}
+	return readings
+}
+
+// matchBook returns the English name of the longest book name at the start
+// of tokens, and how many tokens it takes up, or 0 if they don't start with
+// a book.
+func matchBook(tokens []referenceToken) (string, int) {
+	var book string
+	var n int
+	words := make([]string, 0, maxBookWords)
+	for i := 0; i < len(tokens) && i < maxBookWords; i++ {
+		words = append(words, tokens[i].text)
+		if b, ok := lookupBook(strings.Join(words, " ")); ok {
+			book, n = b, i+1
+		}
+		if tokens[i].sep {
+			break
+		}
+	}
+	return book, n
+}
+
+// normalizePassages tidies passages as typed, joining pieces split by
+// spaces, such as "31:" "1-7", and using a hyphen for every kind of dash.
+func normalizePassages(passages []string) []string {
+	var out []string
+	for _, p := range passages {
+		p = dashes.Replace(p)
+		if n := len(out); n > 0 && (strings.HasSuffix(out[n-1], ":") || strings.HasSuffix(out[n-1], "-") ||
+			strings.HasPrefix(p, ":") || strings.HasPrefix(p, "-")) {
+			out[n-1] += p
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+var dashes = strings.NewReplacer("–", "-", "—", "-", "‐", "-", "‑", "-")
+
+// referenceFormat is how readings are written out for people to read.
+type referenceFormat struct {
+	// style is one of referenceStyles
+	style string
+	// enDash writes ranges with an en dash, as in "1 Samuel 3:1–10". It
+	// doesn't apply to OSIS references.
+	enDash bool
+}
+
+// referenceStyles are the ways of writing readings.
+var referenceStyles = []string{"full", "abbreviated", "osis"}
+
+// defaultReferences writes readings as "1 Samuel 3:1-10".
+var defaultReferences = referenceFormat{style: "full"}
+
+// newReferenceFormat returns the named style, optionally with en dashes.
+func newReferenceFormat(style string, enDash bool) (referenceFormat, error) {
+	for _, s := range referenceStyles {
+		if s == style {
+			return referenceFormat{style: style, enDash: enDash}, nil
+		}
+	}
+	return referenceFormat{}, fmt.Errorf("Unknown reference style %q: expected one of %s", style, strings.Join(referenceStyles, ", "))
+}
+
+// format writes each reading with its book name in the calendar's language,
+// e.g. "Genesis 1-2" and "Psalm 19".
+func (f referenceFormat) format(l *locale, readings []*reading) []string {
+	refs := make([]string, 0, len(readings))
+	for _, r := range readings {
+		b := lookupCanon(r.book)
+		switch {
+		case f.style == "osis" && b != nil:
+			refs = append(refs, strings.Join(osisIDs(r), " "))
+			continue
+		case f.style == "abbreviated" && b != nil && l.tag == "en":
+			// Only English has abbreviations.
+			r = &reading{book: b.abbreviation, passages: r.passages}
+		default:
+			r = &reading{book: l.book(r.book), passages: r.passages}
+		}
+		ref := readingsText([]*reading{r})
+		if f.enDash {
+			ref = strings.ReplaceAll(ref, "-", "–")
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// verseRef is a chapter and verse. A verse of 0 means the whole chapter.
+type verseRef struct {
+	chapter, verse int
+}
+
+// parsePassage reads a passage such as "19", "1-2", "3:1-10" or "1:26-2:3".
+// In books with one chapter, plain numbers are verses. chapter is the
+// chapter of the verses before the passage, as in "119:1-16, 20", or 0.
+// Verse parts like the "a" in "5a" are ignored.
+func (b *canonBook) parsePassage(p string, chapter int) (start, end verseRef, ok bool) {
+	parts := strings.SplitN(p, "-", 2)
+	if start, ok = b.parseVerseRef(parts[0], chapter); !ok {
+		return start, end, false
+	}
+	if len(parts) == 1 {
+		return start, start, true
+	}
+	// "3:1-10" continues in chapter 3; "1-2" and "1:26-2:3" don't.
+	chapter = 0
+	if start.verse != 0 {
+		chapter = start.chapter
+	}
+	end, ok = b.parseVerseRef(parts[1], chapter)
+	return start, end, ok
+}
+
+// parseVerseRef reads "3", "3:16" or, if chapter isn't 0, a verse "16" in
+// that chapter.
+func (b *canonBook) parseVerseRef(s string, chapter int) (verseRef, bool) {
+	s = strings.TrimRightFunc(s, unicode.IsLetter)
+	if i := strings.Index(s, ":"); i >= 0 {
+		c, err1 := strconv.Atoi(s[:i])
+		v, err2 := strconv.Atoi(s[i+1:])
+		return verseRef{c, v}, err1 == nil && err2 == nil
+	}
+	n, err := strconv.Atoi(s)
+	switch {
+	case err != nil:
+		return verseRef{}, false
+	case chapter != 0:
+		return verseRef{chapter, n}, true
+	case b.chapters == 1:
+		return verseRef{1, n}, true
+	default:
+		return verseRef{n, 0}, true
+	}
+}
+
+// osisRef writes a passage of b as an OSIS reference, such as "Gen.1-Gen.2"
+// or "Ps.19", given the chapter the passage continues as for parsePassage.
+// Passages that can't be parsed are written as typed. It also returns the
+// chapter the next passage continues.
+func (b *canonBook) osisRef(p string, chapter int) (string, int) {
+	start, end, ok := b.parsePassage(p, chapter)
+	if !ok {
+		return b.osis + "." + strings.ReplaceAll(p, ":", "."), 0
+	}
+	ref := b.osisVerse(start)
+	if end != start {
+		ref += "-" + b.osisVerse(end)
+	}
+	if end.verse == 0 {
+		return ref, 0
+	}
+	return ref, end.chapter
+}
+
+func (b *canonBook) osisVerse(v verseRef) string {
+	if v.verse == 0 {
+		return fmt.Sprintf("%s.%d", b.osis, v.chapter)
+	}
+	return fmt.Sprintf("%s.%d.%d", b.osis, v.chapter, v.verse)
+}
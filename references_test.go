@@ -0,0 +1,180 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReferences(t *testing.T) {
+	tests := []struct {
+		line string
+		want []*reading
+	}{
+		{
+			line: "Genesis 1-2 Psalm 19",
+			want: []*reading{
+				{book: "Genesis", passages: []string{"1-2"}},
+				{book: "Psalm", passages: []string{"19"}},
+			},
+		},
+		{
+			line: "1 Samuel 17 Psalm 12",
+			want: []*reading{
+				{book: "1 Samuel", passages: []string{"17"}},
+				{book: "Psalm", passages: []string{"12"}},
+			},
+		},
+		{
+			line: "Song of Songs 1-2 Psalm 45",
+			want: []*reading{
+				{book: "Song of Songs", passages: []string{"1-2"}},
+				{book: "Psalm", passages: []string{"45"}},
+			},
+		},
+		{
+			line: "Acts of the Apostles 1 Psalm 2",
+			want: []*reading{
+				{book: "Acts of the Apostles", passages: []string{"1"}},
+				{book: "Psalm", passages: []string{"2"}},
+			},
+		},
+		{
+			line: "Genesis 50 Jude",
+			want: []*reading{
+				{book: "Genesis", passages: []string{"50"}},
+				{book: "Jude"},
+			},
+		},
+		{
+			line: "Genesis 12-13 Job 1 Proverbs 1:1-7",
+			want: []*reading{
+				{book: "Genesis", passages: []string{"12-13"}},
+				{book: "Job", passages: []string{"1"}},
+				{book: "Proverbs", passages: []string{"1:1-7"}},
+			},
+		},
+		{
+			line: "1 Kings 1 2 Kings 2 Psalm 3",
+			want: []*reading{
+				{book: "1 Kings", passages: []string{"1"}},
+				{book: "2 Kings", passages: []string{"2"}},
+				{book: "Psalm", passages: []string{"3"}},
+			},
+		},
+		{
+			line: "2 John, 3 John 1 Timothy 4-6",
+			want: []*reading{
+				{book: "2 John"},
+				{book: "3 John", joined: true},
+				{book: "1 Timothy", passages: []string{"4-6"}},
+			},
+		},
+		{
+			line: "Genesis 1, John 3",
+			want: []*reading{
+				{book: "Genesis", passages: []string{"1"}},
+				{book: "John", passages: []string{"3"}},
+			},
+		},
+		{
+			line: "Genesis 1 John 3",
+			want: []*reading{
+				{book: "Genesis"},
+				{book: "1 John", passages: []string{"3"}},
+			},
+		},
+		{
+			line: "1 Samuel 3:1-10, 15;Song of Solomon 2; Psalm 3",
+			want: []*reading{
+				{book: "1 Samuel", passages: []string{"3:1-10", "15"}},
+				{book: "Song of Solomon", passages: []string{"2"}},
+				{book: "Psalm", passages: []string{"3"}},
+			},
+		},
+		{
+			line: "Awit ng mga Awit 1 Awit 45",
+			want: []*reading{
+				{book: "Song of Songs", passages: []string{"1"}},
+				{book: "Psalm", passages: []string{"45"}},
+			},
+		},
+		{
+			line: "Génesis 1-2 Salmo 19",
+			want: []*reading{
+				{book: "Genesis", passages: []string{"1-2"}},
+				{book: "Psalm", passages: []string{"19"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := parseReferences(tt.line)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %s, want %s", readingsText(got), readingsText(tt.want))
+			}
+		})
+	}
+}
+
+func TestNormalizePassages(t *testing.T) {
+	tests := map[string][]string{
+		"Proverbs 31: 1-7":     {"31:1-7"},
+		"Isaiah 40:1 - 11":     {"40:1-11"},
+		"Luke 1:26–38, 2:1—20": {"1:26-38", "2:1-20"},
+	}
+	for line, want := range tests {
+		got := parseReferences(line)
+		if len(got) != 1 || !reflect.DeepEqual(got[0].passages, want) {
+			t.Errorf("parseReferences(%q) = %s, want passages %q", line, readingsText(got), want)
+		}
+	}
+}
+
+func TestReferenceFormat(t *testing.T) {
+	readings := parseReferences("1 Samuel 3:1-10, 15 Psalm 19 Genesis 1:26-2:3 Jude 3 Philemon")
+	tests := []struct {
+		style  string
+		enDash bool
+		lang   string
+		want   []string
+	}{
+		{"full", false, "en", []string{"1 Samuel 3:1-10, 15", "Psalm 19", "Genesis 1:26-2:3", "Jude 3", "Philemon"}},
+		{"full", true, "en", []string{"1 Samuel 3:1–10, 15", "Psalm 19", "Genesis 1:26–2:3", "Jude 3", "Philemon"}},
+		{"full", false, "es", []string{"1 Samuel 3:1-10, 15", "Salmo 19", "Génesis 1:26-2:3", "Judas 3", "Filemón"}},
+		{"abbreviated", false, "en", []string{"1 Sam 3:1-10, 15", "Ps 19", "Gen 1:26-2:3", "Jude 3", "Phlm"}},
+		{"abbreviated", false, "es", []string{"1 Samuel 3:1-10, 15", "Salmo 19", "Génesis 1:26-2:3", "Judas 3", "Filemón"}},
+		{"osis", true, "en", []string{"1Sam.3.1-1Sam.3.10 1Sam.3.15", "Ps.19", "Gen.1.26-Gen.2.3", "Jude.1.3", "Phlm"}},
+	}
+	for _, tt := range tests {
+		f, err := newReferenceFormat(tt.style, tt.enDash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.format(locales[tt.lang], readings); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s (en dash %v, %s): got %q, want %q", tt.style, tt.enDash, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestCanonCoversBooks(t *testing.T) {
+	for _, name := range []string{"Genesis", "Psalms", "Psalm", "Song of Solomon", "Acts of the Apostles", "Revelation"} {
+		if _, ok := books[name]; !ok {
+			t.Errorf("%s is not a book", name)
+		}
+	}
+	if len(books) != len(canon)+len(canonAliases) {
+		t.Errorf("%d books, want %d", len(books), len(canon)+len(canonAliases))
+	}
+	if len(canon) != 73 {
+		t.Errorf("canon has %d books, want 73", len(canon))
+	}
+}
+
+func TestReadingIDs(t *testing.T) {
+	readings := parseReferences("Salmo 119:1-16, 20 2 Juan Acts of the Apostles 1")
+	want := "\nX-BIBLE-OSIS:Ps.119.1-Ps.119.16 Ps.119.20 2John Acts.1" +
+		"\nX-BIBLE-USFM:PSA 2JN ACT"
+	if got := readingIDs(readings); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
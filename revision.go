@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ignoredProperties change on every run and don't count as an edit to an
+// event.
+var ignoredProperties = map[string]struct{}{
+	"DTSTAMP":       struct{}{},
+	"SEQUENCE":      struct{}{},
+	"LAST-MODIFIED": struct{}{},
+}
+
+// revisions decides the SEQUENCE and LAST-MODIFIED of each event by comparing
+// it with the same UID in a previously generated calendar. Clients use
+// SEQUENCE to notice that an already imported event has been corrected.
+type revisions struct {
+	stamp    string
+	previous map[string]*component
+}
+
+// loadRevisions reads the calendar at path, if any, to compare against.
+func loadRevisions(path string, stamp time.Time) (*revisions, error) {
+	r := &revisions{stamp: formatTimestamp(stamp), previous: make(map[string]*component)}
+	if path == "" {
+		return r, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	calendars, err := parseICS(f)
+	if err != nil {
+		return nil, fmt.Errorf("Reading previous calendar %s: %v", path, err)
+	}
+	for _, cal := range calendars {
+		for _, ev := range cal.children("VEVENT") {
+			if uid := ev.value("UID"); uid != "" {
+				r.previous[uid] = ev
+			}
+		}
+	}
+	return r, nil
+}
+
+// revise renders the event with the given UID. Unchanged events keep their
+// previous SEQUENCE and LAST-MODIFIED; changed events get the next SEQUENCE
+// and are marked as modified now.
+func (r *revisions) revise(uid string, render func(sequence int, modified string) string) (string, error) {
+	current := render(0, r.stamp)
+	prev, ok := r.previous[uid]
+	if !ok {
+		return current, nil
+	}
+
+	sequence, _ := strconv.Atoi(prev.value("SEQUENCE"))
+	parsed, err := parseICS(strings.NewReader(current))
+	if err != nil {
+		return "", err
+	}
+	if len(parsed) == 1 && sameContent(prev, parsed[0]) {
+		modified := prev.value("LAST-MODIFIED")
+		if modified == "" {
+			modified = prev.value("DTSTAMP")
+		}
+		return render(sequence, modified), nil
+	}
+	return render(sequence+1, r.stamp), nil
+}
+
+// sameContent reports whether a and b are equal apart from the bookkeeping
+// properties in ignoredProperties.
+func sameContent(a, b *component) bool {
+	if a.name != b.name {
+		return false
+	}
+	ap, bp := significant(a.properties), significant(b.properties)
+	if len(ap) != len(bp) || len(a.components) != len(b.components) {
+		return false
+	}
+	for i := range ap {
+		if ap[i].name != bp[i].name || ap[i].value != bp[i].value || len(ap[i].params) != len(bp[i].params) {
+			return false
+		}
+		for k, v := range ap[i].params {
+			if bp[i].params[k] != v {
+				return false
+			}
+		}
+	}
+	for i := range a.components {
+		if !sameContent(a.components[i], b.components[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func significant(properties []*property) []*property {
+	var kept []*property
+	for _, p := range properties {
+		if _, ignored := ignoredProperties[p.name]; !ignored {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// generationTime is the DTSTAMP for this run. An explicit override, or else
+// the SOURCE_DATE_EPOCH environment variable, makes output reproducible.
+func generationTime(override string) (time.Time, error) {
+	if override != "" {
+		for _, layout := range []string{"20060102T150405Z", time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, override); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("Invalid timestamp %q: expected RFC 3339 or 20060102T150405Z", override)
+	}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		secs, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Invalid SOURCE_DATE_EPOCH %q: %v", epoch, err)
+		}
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	return time.Now().UTC(), nil
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setenv sets an environment variable for the rest of the test.
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestGenerationTime(t *testing.T) {
+	want := time.Date(2021, 1, 12, 15, 14, 54, 0, time.UTC)
+	tests := []struct {
+		override, epoch string
+		want            time.Time
+		wantErr         bool
+	}{
+		{override: "20210112T151454Z", want: want},
+		{override: "2021-01-12T15:14:54Z", want: want},
+		{override: "2021-01-12T16:14:54+01:00", want: want},
+		{override: "2021-01-12", want: time.Date(2021, 1, 12, 0, 0, 0, 0, time.UTC)},
+		{override: "20210112T151454Z", epoch: "0", want: want},
+		{override: "yesterday", wantErr: true},
+		{epoch: "1610464494", want: want},
+		{epoch: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		setenv(t, "SOURCE_DATE_EPOCH", tt.epoch)
+		got, err := generationTime(tt.override)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("generationTime(%q) with SOURCE_DATE_EPOCH=%q = %v, want an error", tt.override, tt.epoch, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("generationTime(%q) with SOURCE_DATE_EPOCH=%q = %v, %v, want %v", tt.override, tt.epoch, got, err, tt.want)
+		}
+	}
+}
+
+func TestSameContent(t *testing.T) {
+	const event = "BEGIN:VEVENT\r\nUID:day-1\r\nDTSTAMP:20210112T151454Z\r\nSEQUENCE:0\r\n" +
+		"DTSTART;VALUE=DATE:20210101\r\nSUMMARY:Day 1\r\nEND:VEVENT\r\n"
+	tests := []struct {
+		name, other string
+		want        bool
+	}{
+		{"identical", event, true},
+		{"bookkeeping", strings.NewReplacer("DTSTAMP:20210112T151454Z", "DTSTAMP:20220101T000000Z", "SEQUENCE:0", "SEQUENCE:3\r\nLAST-MODIFIED:20220101T000000Z").Replace(event), true},
+		{"value", strings.Replace(event, "SUMMARY:Day 1", "SUMMARY:Day 2", 1), false},
+		{"param", strings.Replace(event, "VALUE=DATE:20210101", "VALUE=DATE-TIME:20210101", 1), false},
+		{"property", strings.Replace(event, "END:VEVENT", "LOCATION:Hall\r\nEND:VEVENT", 1), false},
+		{"component", strings.Replace(event, "END:VEVENT", "BEGIN:VALARM\r\nACTION:DISPLAY\r\nEND:VALARM\r\nEND:VEVENT", 1), false},
+	}
+	a, err := parseICS(strings.NewReader(event))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		b, err := parseICS(strings.NewReader(tt.other))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := sameContent(a[0], b[0]); got != tt.want {
+			t.Errorf("%s: sameContent = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestRevise renders testdata/notes.txt against a previous calendar of it
+// with day 2 changed, checking that only day 2 gets a new SEQUENCE and
+// LAST-MODIFIED.
+func TestRevise(t *testing.T) {
+	first := time.Date(2021, 1, 12, 15, 14, 54, 0, time.UTC)
+	second := time.Date(2021, 2, 1, 9, 0, 0, 0, time.UTC)
+	notes, err := ioutil.ReadFile("testdata/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := func(s string) planSource {
+		return func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(s)), nil
+		}
+	}
+
+	f, err := ioutil.TempFile("", "previous-*.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	g := newTestGenerator(t)
+	if g.revs, err = loadRevisions("", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.writeCalendar(f, plan(string(notes)), nil); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	g = newTestGenerator(t)
+	if g.revs, err = loadRevisions(f.Name(), second); err != nil {
+		t.Fatal(err)
+	}
+	changed := strings.Replace(string(notes), "Day 2 Genesis 3-4", "Day 2 Genesis 3-5", 1)
+	var buf bytes.Buffer
+	if err := g.writeCalendar(&buf, plan(changed), nil); err != nil {
+		t.Fatal(err)
+	}
+	cals, err := parseICS(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := cals[0].children("VEVENT")
+	if len(events) == 0 {
+		t.Fatal("no events")
+	}
+	revised := 0
+	for _, ev := range events {
+		sequence, modified := "0", formatTimestamp(first)
+		if strings.HasPrefix(unescapeText(ev.value("SUMMARY")), "Day 2:") {
+			sequence, modified = "1", formatTimestamp(second)
+			revised++
+		}
+		if got := ev.value("SEQUENCE"); got != sequence {
+			t.Errorf("%s: SEQUENCE %s, want %s", ev.value("SUMMARY"), got, sequence)
+		}
+		if got := ev.value("LAST-MODIFIED"); got != modified {
+			t.Errorf("%s: LAST-MODIFIED %s, want %s", ev.value("SUMMARY"), got, modified)
+		}
+		if got := ev.value("DTSTAMP"); got != formatTimestamp(second) {
+			t.Errorf("%s: DTSTAMP %s, want %s", ev.value("SUMMARY"), got, formatTimestamp(second))
+		}
+	}
+	if revised != 1 {
+		t.Errorf("%d events for day 2, want 1", revised)
+	}
+}
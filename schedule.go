@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// schedule decides when each day of the plan takes place. By default events
+// are all-day; a timed schedule places each reading at a fixed local time in
+// a named time zone.
+type schedule struct {
+	start    time.Time
+	timed    bool
+	at       time.Duration
+	duration time.Duration
+	loc      *time.Location
+	// once is set when events don't recur yearly, so days after the first
+	// year of the plan fall on later dates.
+	once bool
+}
+
+// newSchedule builds a schedule starting on start. If at is empty the schedule
+// produces all-day events and the remaining arguments are ignored.
+func newSchedule(start time.Time, at string, duration time.Duration, tz string) (*schedule, error) {
+	s := &schedule{start: start}
+	if at == "" {
+		return s, nil
+	}
+	offset, err := parseClock(at)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid start time %q: expected HH:MM", at)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("Invalid duration %s: must be positive", duration)
+	}
+	if tz == "" {
+		return nil, errors.New("Timed events need a time zone")
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown time zone %q: %v", tz, err)
+	}
+	s.timed = true
+	s.at = offset
+	s.duration = duration
+	s.loc = loc
+	return s, nil
+}
+
+// bounds returns the DTSTART and DTEND values, including parameters, for the
+// given day of the plan.
+func (s *schedule) bounds(day int) (dtstart, dtend string) {
+	year, month, date := s.start.Date()
+	if !s.timed {
+		return ";VALUE=DATE:" + formatDate(time.Date(year, month, date+day-1, 0, 0, 0, 0, time.UTC)),
+			";VALUE=DATE:" + formatDate(time.Date(year, month, date+day, 0, 0, 0, 0, time.UTC))
+	}
+	hour, min := int(s.at/time.Hour), int(s.at%time.Hour/time.Minute)
+	begin := time.Date(year, month, date+day-1, hour, min, 0, 0, s.loc)
+	end := begin.Add(s.duration).In(s.loc)
+	param := ";TZID=" + s.loc.String() + ":"
+	return param + formatDateTime(begin), param + formatDateTime(end)
+}
+
+// date returns the first date of the given day of the plan, at midnight UTC.
+func (s *schedule) date(day int) time.Time {
+	year, month, date := s.start.Date()
+	return time.Date(year, month, date+day-1, 0, 0, 0, 0, time.UTC)
+}
+
+// startOffset is how long after local midnight each event begins.
+func (s *schedule) startOffset() time.Duration {
+	if !s.timed {
+		return 0
+	}
+	return s.at
+}
+
+// timezone returns the VTIMEZONE component needed by timed events, or an
+// empty string for all-day schedules.
+func (s *schedule) timezone() string {
+	if !s.timed {
+		return ""
+	}
+	return generateTimezone(s.loc, s.start.Year())
+}
+
+// parseClock parses a local time of day such as 06:30 into an offset from
+// midnight.
+func parseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func formatDateTime(t time.Time) string {
+	return fmt.Sprintf("%sT%02d%02d%02d", formatDate(t), t.Hour(), t.Minute(), t.Second())
+}
+
+// readingSchedule returns the all-day schedule digests and notifications use
+// to find the plan day on a date, for a calendar with the given recurrence.
+func readingSchedule(start time.Time, recurrence string) (*schedule, error) {
+	if _, ok := recurrences[recurrence]; !ok {
+		return nil, fmt.Errorf("Unknown recurrence %q: expected yearly or none", recurrence)
+	}
+	return &schedule{start: start, once: recurrence == "none"}, nil
+}
+
+// dayOn returns the plan day that falls on date. Events recur yearly unless
+// once is set, so the date is matched against the first year of the
+// schedule. Dates that don't occur in that year, like February 29, have no
+// day.
+func (s *schedule) dayOn(date time.Time) (int, bool) {
+	year, month, day := date.Date()
+	start := time.Date(s.start.Year(), s.start.Month(), s.start.Day(), 0, 0, 0, 0, time.UTC)
+	if s.once {
+		t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		if t.Before(start) {
+			return 0, false
+		}
+		return int(t.Sub(start).Hours()/24) + 1, true
+	}
+	t := time.Date(start.Year(), month, day, 0, 0, 0, 0, time.UTC)
+	if t.Month() != month {
+		return 0, false
+	}
+	if t.Before(start) {
+		t = time.Date(start.Year()+1, month, day, 0, 0, 0, 0, time.UTC)
+		if t.Month() != month {
+			return 0, false
+		}
+	}
+	return int(t.Sub(start).Hours()/24) + 1, true
+}
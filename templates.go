@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// eventTemplates replace the built-in SUMMARY and DESCRIPTION of events, and
+// add a LOCATION, using Go text/template syntax. Each template is executed
+// with an eventData, e.g.
+//
+//	{{.Period}}, day {{.Day}}: {{join .Readings "; "}}
+//
+// A nil template keeps the built-in text, or no LOCATION.
+type eventTemplates struct {
+	summary, description, location *template.Template
+}
+
+// eventData is what event templates can show.
+type eventData struct {
+	Day int
+	// Date is the day's first date, at midnight UTC.
+	Date   time.Time
+	Period string
+	Intro  string
+	// Readings are in the calendar's language, e.g. "Génesis 1-2".
+	Readings []string
+	// Tracks are the readings grouped by track, in the order the tracks
+	// first appear.
+	Tracks []eventTrack
+	Notes  []string
+	Links  []eventLink
+}
+
+type eventTrack struct {
+	Name     string
+	Readings []string
+}
+
+type eventLink struct {
+	Text, URL string
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// newEventTemplates parses the templates, any of which may be empty. It
+// returns nil if all of them are.
+func newEventTemplates(summary, description, location string) (*eventTemplates, error) {
+	if summary == "" && description == "" && location == "" {
+		return nil, nil
+	}
+	t := &eventTemplates{}
+	var err error
+	if t.summary, err = parseEventTemplate("summary", summary); err != nil {
+		return nil, err
+	}
+	if t.description, err = parseEventTemplate("description", description); err != nil {
+		return nil, err
+	}
+	if t.location, err = parseEventTemplate("location", location); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseEventTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s template: %v", name, err)
+	}
+	return t, nil
+}
+
+// newEventData collects what templates can show about d, which is on date.
+func newEventData(l *locale, date time.Time, d *day, p *descriptionParts) *eventData {
+	data := &eventData{
+		Day:      d.number,
+		Date:     date,
+		Period:   d.period.name(l),
+		Intro:    p.intro,
+		Readings: p.references,
+		Notes:    p.notes,
+	}
+	tracks := make(map[string]int)
+	for i, r := range p.readings {
+		name := trackName(l, r.track)
+		j, ok := tracks[name]
+		if !ok {
+			j = len(data.Tracks)
+			tracks[name] = j
+			data.Tracks = append(data.Tracks, eventTrack{Name: name})
+		}
+		data.Tracks[j].Readings = append(data.Tracks[j].Readings, p.references[i])
+	}
+	for _, link := range p.links {
+		data.Links = append(data.Links, eventLink{Text: link.text, URL: link.url})
+	}
+	return data
+}
+
+// execute renders t with data, returning "" for a nil template.
+func execute(t *template.Template, data *eventData) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	var s strings.Builder
+	if err := t.Execute(&s, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(s.String()), nil
+}
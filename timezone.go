@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const vtimezone = `BEGIN:VTIMEZONE
+TZID:%s
+%s
+END:VTIMEZONE`
+
+const observance = `BEGIN:%s
+DTSTART:%s
+TZOFFSETFROM:%s
+TZOFFSETTO:%s
+TZNAME:%s%s
+END:%s`
+
+// transition is a change of UTC offset in a time zone.
+type transition struct {
+	at       time.Time
+	from, to int
+	name     string
+}
+
+// generateTimezone builds a VTIMEZONE component for loc from the transitions
+// Go's zone database reports during year. Zones with a regular yearly pattern
+// get a recurrence rule so clients can extend it to later years.
+func generateTimezone(loc *time.Location, year int) string {
+	transitions := findTransitions(loc, year)
+	if len(transitions) == 0 {
+		name, offset := time.Date(year, 1, 1, 0, 0, 0, 0, loc).Zone()
+		return fmt.Sprintf(vtimezone, loc.String(), fmt.Sprintf(observance,
+			"STANDARD", "19700101T000000", formatOffset(offset), formatOffset(offset), name, "", "STANDARD"))
+	}
+
+	standard := transitions[0].to
+	for _, t := range transitions {
+		if t.to < standard {
+			standard = t.to
+		}
+	}
+	recurring := len(transitions) == 2
+
+	components := make([]string, 0, len(transitions))
+	for _, t := range transitions {
+		kind := "STANDARD"
+		if t.to > standard {
+			kind = "DAYLIGHT"
+		}
+		local := t.at.In(time.FixedZone("", t.from))
+		var rrule string
+		if recurring {
+			rrule = "\n" + yearlyRule(local)
+		}
+		components = append(components, fmt.Sprintf(observance,
+			kind, formatDateTime(local), formatOffset(t.from), formatOffset(t.to), t.name, rrule, kind))
+	}
+	return fmt.Sprintf(vtimezone, loc.String(), strings.Join(components, "\n"))
+}
+
+// findTransitions scans year hour by hour and narrows each offset change down
+// to the second it happens.
+func findTransitions(loc *time.Location, year int) []transition {
+	var transitions []transition
+	t := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+	end := t.AddDate(1, 0, 0)
+	_, prev := t.Zone()
+	for t.Before(end) {
+		next := t.Add(time.Hour)
+		if _, offset := next.Zone(); offset != prev {
+			lo, hi := t, next
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, o := mid.Zone(); o == prev {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			name, _ := hi.Zone()
+			transitions = append(transitions, transition{at: hi, from: prev, to: offset, name: name})
+			prev = offset
+		}
+		t = next
+	}
+	return transitions
+}
+
+// yearlyRule describes the day of t as a weekday of its month, e.g. the
+// second Sunday of March or the last Sunday of October.
+func yearlyRule(t time.Time) string {
+	weekday := strings.ToUpper(t.Weekday().String()[:2])
+	nth := (t.Day()-1)/7 + 1
+	if t.AddDate(0, 0, 7).Month() != t.Month() {
+		nth = -1
+	}
+	return fmt.Sprintf("RRULE:FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", t.Month(), nth, weekday)
+}
+
+// formatOffset renders a UTC offset in seconds as +HHMM, or +HHMMSS if it
+// isn't a whole number of minutes.
+func formatOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	s := fmt.Sprintf("%c%02d%02d", sign, offset/3600, offset%3600/60)
+	if sec := offset % 60; sec != 0 {
+		s += fmt.Sprintf("%02d", sec)
+	}
+	return s
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The tracks of plans that don't name them. Like plan.txt, each day reads a
+// narrative book, maybe a supporting book, and a psalm or some proverbs.
+const (
+	narrativeTrack  = "Narrative"
+	supportingTrack = "Supporting"
+	psalmTrack      = "Psalm"
+	wisdomTrack     = "Wisdom"
+)
+
+// inferTrack returns the track of the i'th of a day's readings when the plan
+// doesn't say: psalms and proverbs have their own tracks, the first other
+// reading is the narrative and any more are supporting. Books joined by a
+// comma, as in "2 John, 3 John", share the track of the first.
+func inferTrack(readings []*reading, i int) string {
+	if t := bookTrack(readings[i].book); t != "" {
+		return t
+	}
+	if readings[i].joined && i > 0 {
+		if t := readings[i-1].track; t != "" {
+			return t
+		}
+		return inferTrack(readings, i-1)
+	}
+	for _, r := range readings[:i] {
+		if bookTrack(r.book) == "" {
+			return supportingTrack
+		}
+	}
+	return narrativeTrack
+}
+
+// bookTrack returns the track of books that always have their own, or "".
+func bookTrack(book string) string {
+	if b := lookupCanon(book); b != nil {
+		switch b.name {
+		case "Psalms":
+			return psalmTrack
+		case "Proverbs":
+			return wisdomTrack
+		}
+	}
+	return ""
+}
+
+// trackLabel matches a track label in a plan, such as "[New Testament]".
+var trackLabel = regexp.MustCompile(`\[([^\]]*)\]`)
+
+// parseTrackedReadings reads the readings of a day line. A label such as
+// "[Narrative]" puts the readings after it in that track, as in
+// "[Narrative] Genesis 1-2 [Psalm] Psalm 19"; readings without a label are
+// given the track inferTrack would.
+func parseTrackedReadings(s string) []*reading {
+	var readings []*reading
+	track, start := "", 0
+	add := func(text string) {
+		for _, r := range parseReferences(text) {
+			r.track = track
+			readings = append(readings, r)
+		}
+	}
+	for _, m := range trackLabel.FindAllStringSubmatchIndex(s, -1) {
+		add(s[start:m[0]])
+		track, start = strings.TrimSpace(s[m[2]:m[3]]), m[1]
+	}
+	add(s[start:])
+	inferTracks(readings)
+	return readings
+}
+
+// inferTracks puts readings without a track in the one inferTrack returns.
+func inferTracks(readings []*reading) {
+	for i, r := range readings {
+		if r.track == "" {
+			r.track = inferTrack(readings, i)
+		}
+	}
+}
+
+// labelTracks returns whether a day's readings need track labels to be read
+// back the same, because some aren't in the track that would be inferred.
+func labelTracks(readings []*reading) bool {
+	for i, r := range readings {
+		if r.track != "" && r.track != inferTrack(readings, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// trackName returns the name of a track in the calendar's language. Tracks
+// named in the plan are shown as written.
+func trackName(l *locale, track string) string {
+	switch track {
+	case narrativeTrack, supportingTrack, psalmTrack, wisdomTrack:
+		return l.message(strings.ToLower(track))
+	}
+	return track
+}
+
+// filterTracks returns the readings in one of tracks, compared without
+// regard to case. With no tracks, every reading is kept.
+func filterTracks(readings []*reading, tracks []string) []*reading {
+	if len(tracks) == 0 {
+		return readings
+	}
+	var kept []*reading
+	for _, r := range readings {
+		for _, t := range tracks {
+			if strings.EqualFold(r.track, t) {
+				kept = append(kept, r)
+				break
+			}
+		}
+	}
+	return kept
+}
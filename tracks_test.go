@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseTrackedReadings(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Genesis 1-2 Psalm 19", "Narrative:Genesis Psalm:Psalm"},
+		{"1 Samuel 1-2 Song of Songs 2 Acts 1:1-11 Proverbs 3", "Narrative:1 Samuel Supporting:Song of Songs Supporting:Acts Wisdom:Proverbs"},
+		{"Psalm 1 Genesis 1", "Psalm:Psalm Narrative:Genesis"},
+		{"[Gospels] Matthew 1 Mark 1 [Psalm] Psalm 19", "Gospels:Matthew Gospels:Mark Psalm:Psalm"},
+		{"Genesis 1 [Evening] Psalm 4", "Narrative:Genesis Evening:Psalm"},
+		{"2 John, 3 John 1 Timothy 4-6 Proverbs 30:29-33", "Narrative:2 John Narrative:3 John Supporting:1 Timothy Wisdom:Proverbs"},
+		{"2 John 3 John 1 Timothy 4-6", "Narrative:2 John Supporting:3 John Supporting:1 Timothy"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, r := range parseTrackedReadings(tt.in) {
+			got = append(got, r.track+":"+r.book)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("parseTrackedReadings(%q) = %q, want %q", tt.in, strings.Join(got, " "), tt.want)
+		}
+	}
+}
+
+func TestWritePlanLabelsTracks(t *testing.T) {
+	for _, line := range []string{
+		"Day 1 Genesis 1-2 Psalm 19",
+		"Day 1 [Gospels] Matthew 1 [Psalm] Psalm 19",
+		"Day 1 2 John, 3 John 1 Timothy 4-6 Proverbs 30:29-33",
+	} {
+		days, err := parsePlan(strings.NewReader("Early World\n" + line + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := writePlan(&buf, days); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Split(buf.String(), "\n")[1]; got != line {
+			t.Errorf("got %q, want %q", got, line)
+		}
+	}
+}
+
+// TestTrackFilter checks that a calendar of one track leaves out days
+// without it, keeping the UIDs of the rest and the introduction of a period
+// whose first day was left out.
+func TestTrackFilter(t *testing.T) {
+	plan := func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("Early World\n> In the beginning.\n" +
+			"Day 1 Genesis 1-2 Psalm 19\n" +
+			"Day 2 Genesis 3-4 Song of Songs 2 Acts 1:1-11 Psalm 104\n")), nil
+	}
+	g := newTestGenerator(t)
+	g.filter = &readingFilter{tracks: []string{"supporting"}}
+	g.trackLabels = true
+	var buf bytes.Buffer
+	if err := g.writeCalendar(&buf, plan, nil); err != nil {
+		t.Fatal(err)
+	}
+	cals, err := parseICS(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := cals[0].children("VEVENT")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	ev := events[0]
+	if uid, _ := g.uid(2); ev.value("UID") != uid {
+		t.Errorf("UID = %s, want day 2's %s", ev.value("UID"), uid)
+	}
+	description := unescapeText(ev.value("DESCRIPTION"))
+	for _, want := range []string{"In the beginning.", "Supporting: Song of Songs 2", "Supporting: Acts 1:1-11"} {
+		if !strings.Contains(description, want) {
+			t.Errorf("description %q doesn't contain %q", description, want)
+		}
+	}
+	if strings.Contains(description, "Psalm") {
+		t.Errorf("description %q contains other tracks", description)
+	}
+
+	g.filter = &readingFilter{tracks: []string{"Psalm"}}
+	buf.Reset()
+	if err := g.writeCalendar(&buf, planFile("testdata/notes.txt"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if cals, err = parseICS(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(cals[0].children("VEVENT")); n != 5 {
+		t.Errorf("got %d Psalm events, want 5", n)
+	}
+}
+
+// TestPlanJoinedBooksShareTrack checks that plan.txt's "2 John, 3 John" on
+// day 357 are both read as the narrative, not 3 John as supporting.
+func TestPlanJoinedBooksShareTrack(t *testing.T) {
+	f, err := os.Open("plan.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	days, err := parsePlan(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range days[356].readings {
+		got = append(got, r.track+":"+r.book)
+	}
+	want := "Narrative:2 John Narrative:3 John Supporting:1 Timothy Wisdom:Proverbs"
+	if strings.Join(got, " ") != want {
+		t.Errorf("day %d: got %q, want %q", days[356].number, strings.Join(got, " "), want)
+	}
+}